@@ -0,0 +1,252 @@
+package redtape
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attributeValuePath is the attribute path referring to the value passed to Condition#Meets.
+const attributeValuePath = "value"
+
+// attributeValue resolves path for the attribute conditions, where "value" is the condition value and any
+// other path is looked up through Request#Lookup.
+func attributeValue(path string, val interface{}, r *Request) (interface{}, bool) {
+	if path == attributeValuePath {
+		return val, val != nil
+	}
+
+	if r == nil {
+		return nil, false
+	}
+
+	return r.Lookup(path)
+}
+
+// attributeValues resolves the left and right paths of an attribute condition.
+func attributeValues(left, right string, val interface{}, r *Request) (interface{}, interface{}, bool) {
+	l, ok := attributeValue(left, val, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	rv, ok := attributeValue(right, val, r)
+
+	return l, rv, ok
+}
+
+// decodeAttributeCondition decodes opts into c and validates the paths it references.
+func decodeAttributeCondition(opts map[string]interface{}, c Condition, paths func() []string) error {
+	if err := decodeCondition(opts, c); err != nil {
+		return err
+	}
+
+	for _, p := range paths() {
+		if p == attributeValuePath {
+			continue
+		}
+
+		if err := ValidatePath(p); err != nil {
+			return fmt.Errorf("condition %s: %w", c.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// AttributeEqualsCondition matches when the values found at the paths Left and Right are equal, such as
+// meta.owner_id and subject.id. Paths are resolved through Request#Lookup and the path "value" refers to the
+// condition value. Numbers are compared numerically and other values by their string form.
+type AttributeEqualsCondition struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AttributeEqualsCondition) Name() string {
+	return "attribute_equals"
+}
+
+// Meets evaluates true when both paths are set to equal values.
+func (c *AttributeEqualsCondition) Meets(val interface{}, r *Request) bool {
+	l, rv, ok := attributeValues(c.Left, c.Right, val, r)
+
+	return ok && valuesEqual(l, rv)
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, validating the paths.
+func (c *AttributeEqualsCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	return decodeAttributeCondition(opts, c, func() []string { return []string{c.Left, c.Right} })
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AttributeEqualsCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"left": c.Left, "right": c.Right}
+}
+
+// AttributeNotEqualsCondition matches when the values found at the paths Left and Right differ. It does not
+// match when either path is not set.
+type AttributeNotEqualsCondition struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AttributeNotEqualsCondition) Name() string {
+	return "attribute_not_equals"
+}
+
+// Meets evaluates true when both paths are set to different values.
+func (c *AttributeNotEqualsCondition) Meets(val interface{}, r *Request) bool {
+	l, rv, ok := attributeValues(c.Left, c.Right, val, r)
+
+	return ok && !valuesEqual(l, rv)
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, validating the paths.
+func (c *AttributeNotEqualsCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	return decodeAttributeCondition(opts, c, func() []string { return []string{c.Left, c.Right} })
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AttributeNotEqualsCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"left": c.Left, "right": c.Right}
+}
+
+// AttributeInCondition matches when the value found at the path Left is an element of the list found at the
+// path Right, such as subject.id in meta.document.editors.
+type AttributeInCondition struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AttributeInCondition) Name() string {
+	return "attribute_in"
+}
+
+// Meets evaluates true when the list at Right holds the value at Left.
+func (c *AttributeInCondition) Meets(val interface{}, r *Request) bool {
+	l, rv, ok := attributeValues(c.Left, c.Right, val, r)
+	if !ok {
+		return false
+	}
+
+	for _, v := range toList(rv) {
+		if valuesEqual(l, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, validating the paths.
+func (c *AttributeInCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	return decodeAttributeCondition(opts, c, func() []string { return []string{c.Left, c.Right} })
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AttributeInCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"left": c.Left, "right": c.Right}
+}
+
+// AttributeCompareCondition orders the values found at the paths Left and Right with Operator, one of lt, lte,
+// gt or gte. Values are compared as numbers when both are numeric, as times when both are times and as
+// strings otherwise.
+type AttributeCompareCondition struct {
+	Left     string `json:"left"`
+	Operator string `json:"operator"`
+	Right    string `json:"right"`
+}
+
+var compareOperators = map[string]func(cmp int) bool{
+	"lt":  func(cmp int) bool { return cmp < 0 },
+	"lte": func(cmp int) bool { return cmp <= 0 },
+	"gt":  func(cmp int) bool { return cmp > 0 },
+	"gte": func(cmp int) bool { return cmp >= 0 },
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AttributeCompareCondition) Name() string {
+	return "attribute_compare"
+}
+
+// Meets evaluates true when both paths are set and their values satisfy Operator.
+func (c *AttributeCompareCondition) Meets(val interface{}, r *Request) bool {
+	op, ok := compareOperators[c.Operator]
+	if !ok {
+		return false
+	}
+
+	l, rv, ok := attributeValues(c.Left, c.Right, val, r)
+	if !ok {
+		return false
+	}
+
+	cmp, ok := compareValues(l, rv)
+
+	return ok && op(cmp)
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, validating the paths and operator.
+func (c *AttributeCompareCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	if err := decodeAttributeCondition(opts, c, func() []string { return []string{c.Left, c.Right} }); err != nil {
+		return err
+	}
+
+	if _, ok := compareOperators[c.Operator]; !ok {
+		return fmt.Errorf("condition %s: unknown operator %q", c.Name(), c.Operator)
+	}
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AttributeCompareCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"left": c.Left, "operator": c.Operator, "right": c.Right}
+}
+
+// compareValues orders a and b as numbers, times or strings. Like valuesEqual, a and b are only ordered as
+// numbers when at least one of them is a number.
+func compareValues(a, b interface{}) (int, bool) {
+	if isNumber(a) || isNumber(b) {
+		fa, aok := toFloat(a)
+		fb, bok := toFloat(b)
+
+		if aok && bok {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	if ta, ok := toTime(a); ok {
+		if tb, ok := toTime(b); ok {
+			switch {
+			case ta.Before(tb):
+				return -1, true
+			case ta.After(tb):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	sa, ok := toString(a)
+	if !ok {
+		return 0, false
+	}
+
+	sb, ok := toString(b)
+	if !ok {
+		return 0, false
+	}
+
+	return strings.Compare(sa, sb), true
+}
@@ -0,0 +1,99 @@
+package redtape
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttributeConditions(t *testing.T) {
+	sub := NewSubject("alice", NewRole("editor"))
+	sub.Attributes["level"] = 3
+
+	r := NewSubjectRequest("/docs/1", "edit", sub, "eu", map[string]interface{}{
+		"owner_id": "alice",
+		"user_id":  "alice",
+		"document": map[string]interface{}{
+			"owner":     "bob",
+			"editors":   []string{"carol", "alice"},
+			"min_level": "2",
+			"expires":   "2021-07-06T00:00:00Z",
+		},
+		"now":      "2021-07-05T12:00:00Z",
+		"code":     "007",
+		"codes":    []string{"007", "010"},
+		"lucky":    7,
+		"thousand": "1000",
+		"big":      "1e3",
+		"ten":      "10",
+		"nine":     "9",
+	})
+
+	tests := []struct {
+		name string
+		typ  string
+		opts map[string]interface{}
+		val  interface{}
+		want bool
+	}{
+		{"equals_meta", "attribute_equals", map[string]interface{}{"left": "meta.owner_id", "right": "meta.user_id"}, nil, true},
+		{"equals_subject", "attribute_equals", map[string]interface{}{"left": "meta.document.owner", "right": "subject.id"}, nil, false},
+		{"equals_value", "attribute_equals", map[string]interface{}{"left": "value", "right": "subject.id"}, "alice", true},
+		{"equals_missing", "attribute_equals", map[string]interface{}{"left": "meta.missing", "right": "meta.missing"}, nil, false},
+		{"equals_numeric_strings", "attribute_equals", map[string]interface{}{"left": "meta.code", "right": "value"}, "7", false},
+		{"equals_exponent_strings", "attribute_equals", map[string]interface{}{"left": "meta.big", "right": "meta.thousand"}, nil, false},
+		{"equals_number_string", "attribute_equals", map[string]interface{}{"left": "meta.lucky", "right": "value"}, "7", true},
+		{"not_equals", "attribute_not_equals", map[string]interface{}{"left": "meta.document.owner", "right": "subject.id"}, nil, true},
+		{"not_equals_missing", "attribute_not_equals", map[string]interface{}{"left": "meta.missing", "right": "subject.id"}, nil, false},
+		{"in", "attribute_in", map[string]interface{}{"left": "subject.id", "right": "meta.document.editors"}, nil, true},
+		{"in_roles", "attribute_in", map[string]interface{}{"left": "value", "right": "request.roles"}, "editor", true},
+		{"in_numeric_strings", "attribute_in", map[string]interface{}{"left": "value", "right": "meta.codes"}, "10", false},
+		{"in_missing", "attribute_in", map[string]interface{}{"left": "meta.document.owner", "right": "meta.document.editors"}, nil, false},
+		{"compare_numbers", "attribute_compare", map[string]interface{}{"left": "subject.attr.level", "operator": "gte", "right": "meta.document.min_level"}, nil, true},
+		{"compare_times", "attribute_compare", map[string]interface{}{"left": "meta.now", "operator": "lt", "right": "meta.document.expires"}, nil, true},
+		{"compare_numeric_strings", "attribute_compare", map[string]interface{}{"left": "meta.ten", "operator": "lt", "right": "meta.nine"}, nil, true},
+		{"compare_strings", "attribute_compare", map[string]interface{}{"left": "meta.document.owner", "operator": "gt", "right": "subject.id"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conds, err := NewConditions([]ConditionOptions{{Name: "c", Type: tt.typ, Options: tt.opts}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := conds["c"].Meets(tt.val, r); got != tt.want {
+				t.Errorf("Condition.Meets() = %v, want %v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(conds.Options()[0].Options, tt.opts) {
+				t.Errorf("Conditions.Options() = %v, want %v", conds.Options()[0].Options, tt.opts)
+			}
+		})
+	}
+
+	eq := &AttributeEqualsCondition{Left: "meta.owner_id", Right: "subject.id"}
+	if eq.Meets(nil, nil) {
+		t.Errorf("AttributeEqualsCondition.Meets() = true for nil request")
+	}
+}
+
+func TestAttributeConditionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  string
+		opts map[string]interface{}
+	}{
+		{"unknown_path", "attribute_equals", map[string]interface{}{"left": "request.body", "right": "subject.id"}},
+		{"empty_path", "attribute_in", map[string]interface{}{"left": "subject.id"}},
+		{"empty_segment", "attribute_not_equals", map[string]interface{}{"left": "meta.a..b", "right": "subject.id"}},
+		{"unknown_operator", "attribute_compare", map[string]interface{}{"left": "meta.a", "operator": "<", "right": "meta.b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConditions([]ConditionOptions{{Name: "c", Type: tt.typ, Options: tt.opts}}, nil); err == nil {
+				t.Errorf("NewConditions() error = nil, want error")
+			}
+		})
+	}
+}
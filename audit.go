@@ -24,7 +24,14 @@ type Auditor interface {
 	LogPolicyEffect(req *Request, effect PolicyEffect)
 }
 
-// NewConsoleAuditor returns an Auditor that prints the audit log to stdout.
+// ShadowAuditor is an optional interface for Auditors receiving the would-be effect of matched shadow policies
+// alongside the enforced effect.
+type ShadowAuditor interface {
+	LogShadowEffect(req *Request, effect PolicyEffect, shadow *ShadowDecision)
+}
+
+// NewConsoleAuditor returns an Auditor that prints the audit log to stdout. The console Auditor also
+// implements ShadowAuditor and SoDAuditor.
 func NewConsoleAuditor(lvl AuditLevel) Auditor {
 	return &consoleAuditor{
 		lvl: lvl,
@@ -40,6 +47,9 @@ const (
 	logReq   = "[AUDIT_REQ]:"
 	logAllow = "[AUDIT_ALLOW]:"
 	logDeny  = "[AUDIT_DENY]:"
+
+	shadowfmt = "[AUDIT_SHADOW]: action=%s resource=%s role=%s scope=%s effect=%s shadow=%s diverges=%t policies=%v\n"
+	sodfmt    = "[AUDIT_SOD]: action=%s resource=%s role=%s scope=%s constraint=%s kind=%s subject=%s roles=%v\n"
 )
 
 // LogRequest prints the request to console if AuditLevel is at or above AuditRequest.
@@ -58,3 +68,24 @@ func (a *consoleAuditor) LogPolicyEffect(req *Request, effect PolicyEffect) {
 		log.Printf(logfmt, logAllow, req.Action, req.Resource, req.Role, req.Scope)
 	}
 }
+
+// LogShadowEffect prints the would-be effect of shadow policies to console. Divergent shadow effects are printed
+// if AuditLevel is at or above AuditDeny, all others if it is at or above AuditAllow.
+func (a *consoleAuditor) LogShadowEffect(req *Request, effect PolicyEffect, shadow *ShadowDecision) {
+	if (shadow.Diverges && a.lvl >= AuditDeny) || a.lvl >= AuditAllow {
+		log.Printf(shadowfmt, req.Action, req.Resource, req.Role, req.Scope, effect, shadow.Effect, shadow.Diverges, shadow.Matched)
+	}
+}
+
+// LogSoDViolation prints separation of duty violations to console if AuditLevel is at or above AuditDeny.
+func (a *consoleAuditor) LogSoDViolation(req *Request, v *SoDViolation) {
+	if a.lvl < AuditDeny {
+		return
+	}
+
+	if req == nil {
+		req = &Request{}
+	}
+
+	log.Printf(sodfmt, req.Action, req.Resource, req.Role, req.Scope, v.Constraint.ID, v.Constraint.Kind, v.Subject, v.Roles)
+}
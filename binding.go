@@ -0,0 +1,186 @@
+package redtape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RoleBinding assigns a role to a subject. A binding without Expires never expires.
+type RoleBinding struct {
+	SubjectID string     `json:"subject_id"`
+	RoleID    string     `json:"role_id"`
+	Expires   *time.Time `json:"expires,omitempty"`
+}
+
+// NewRoleBinding returns a RoleBinding of role to subject expiring at expires. A nil expires never expires.
+func NewRoleBinding(subject, role string, expires *time.Time) RoleBinding {
+	return RoleBinding{
+		SubjectID: subject,
+		RoleID:    role,
+		Expires:   expires,
+	}
+}
+
+// Active evaluates true when the binding has not expired at t.
+func (b RoleBinding) Active(t time.Time) bool {
+	return b.Expires == nil || t.Before(*b.Expires)
+}
+
+// RoleBindingManager records which subjects hold which roles. RolesFor and SubjectsFor only return bindings
+// active at the provided time. Binding a role a subject already holds replaces the existing binding.
+type RoleBindingManager interface {
+	Bind(RoleBinding) error
+	Unbind(subject, role string) error
+	RolesFor(subject string, at time.Time) ([]RoleBinding, error)
+	SubjectsFor(role string, at time.Time) ([]RoleBinding, error)
+}
+
+// ContextRoleBindingManager is a RoleBindingManager providing context aware variants of its methods that
+// honor cancellation and deadlines.
+type ContextRoleBindingManager interface {
+	RoleBindingManager
+
+	BindContext(context.Context, RoleBinding) error
+	UnbindContext(ctx context.Context, subject, role string) error
+	RolesForContext(ctx context.Context, subject string, at time.Time) ([]RoleBinding, error)
+	SubjectsForContext(ctx context.Context, role string, at time.Time) ([]RoleBinding, error)
+}
+
+func rolesFor(ctx context.Context, bm RoleBindingManager, subject string, at time.Time) ([]RoleBinding, error) {
+	if cbm, ok := bm.(ContextRoleBindingManager); ok {
+		return cbm.RolesForContext(ctx, subject, at)
+	}
+
+	return bm.RolesFor(subject, at)
+}
+
+// FilterRoleBindings returns the bindings matching subject and role that are active at t. Empty subject or
+// role values match any binding. The result is ordered by subject and role.
+func FilterRoleBindings(bindings []RoleBinding, subject, role string, at time.Time) []RoleBinding {
+	res := []RoleBinding{}
+
+	for _, b := range bindings {
+		if (subject == "" || b.SubjectID == subject) && (role == "" || b.RoleID == role) && b.Active(at) {
+			res = append(res, b)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].SubjectID != res[j].SubjectID {
+			return res[i].SubjectID < res[j].SubjectID
+		}
+
+		return res[i].RoleID < res[j].RoleID
+	})
+
+	return res
+}
+
+type defaultRoleBindingManager struct {
+	bindings map[string]map[string]RoleBinding
+	mu       sync.RWMutex
+}
+
+// NewRoleBindingManager returns a default memory backed RoleBindingManager. The manager implements
+// ContextRoleBindingManager.
+func NewRoleBindingManager() RoleBindingManager {
+	return &defaultRoleBindingManager{
+		bindings: make(map[string]map[string]RoleBinding),
+	}
+}
+
+func (m *defaultRoleBindingManager) Bind(b RoleBinding) error {
+	return m.BindContext(context.Background(), b)
+}
+
+func (m *defaultRoleBindingManager) BindContext(ctx context.Context, b RoleBinding) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if b.SubjectID == "" || b.RoleID == "" {
+		return errors.New("role binding requires a subject and role")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.bindings[b.SubjectID]; !ok {
+		m.bindings[b.SubjectID] = make(map[string]RoleBinding)
+	}
+
+	m.bindings[b.SubjectID][b.RoleID] = b
+
+	return nil
+}
+
+func (m *defaultRoleBindingManager) Unbind(subject, role string) error {
+	return m.UnbindContext(context.Background(), subject, role)
+}
+
+func (m *defaultRoleBindingManager) UnbindContext(ctx context.Context, subject, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.bindings[subject][role]; !ok {
+		return fmt.Errorf("subject %s is not bound to role %s", subject, role)
+	}
+
+	delete(m.bindings[subject], role)
+
+	if len(m.bindings[subject]) == 0 {
+		delete(m.bindings, subject)
+	}
+
+	return nil
+}
+
+func (m *defaultRoleBindingManager) RolesFor(subject string, at time.Time) ([]RoleBinding, error) {
+	return m.RolesForContext(context.Background(), subject, at)
+}
+
+func (m *defaultRoleBindingManager) RolesForContext(ctx context.Context, subject string, at time.Time) ([]RoleBinding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bindings := make([]RoleBinding, 0, len(m.bindings[subject]))
+	for _, b := range m.bindings[subject] {
+		bindings = append(bindings, b)
+	}
+
+	return FilterRoleBindings(bindings, subject, "", at), nil
+}
+
+func (m *defaultRoleBindingManager) SubjectsFor(role string, at time.Time) ([]RoleBinding, error) {
+	return m.SubjectsForContext(context.Background(), role, at)
+}
+
+func (m *defaultRoleBindingManager) SubjectsForContext(ctx context.Context, role string, at time.Time) ([]RoleBinding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var bindings []RoleBinding
+	for _, rb := range m.bindings {
+		if b, ok := rb[role]; ok {
+			bindings = append(bindings, b)
+		}
+	}
+
+	return FilterRoleBindings(bindings, "", role, at), nil
+}
@@ -0,0 +1,176 @@
+package redtape
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is the lifetime of cached decisions when CacheOptions#TTL is not set.
+	DefaultCacheTTL = time.Minute
+	// DefaultCacheSize is the maximum number of cached decisions when CacheOptions#MaxSize is not set.
+	DefaultCacheSize = 1000
+)
+
+// CacheOptions configures the decision cache of an Enforcer. Decisions are keyed on the request resource,
+// action, role, scope, subject ID, the resolved roles of the subject and the values of MetadataKeys. Entries
+// expire after TTL and the least recently used entries are evicted once MaxSize is reached.
+//
+// A decision is only cached when every candidate policy depends on nothing outside the key: policies with
+// validity windows, templates reading other metadata or subject attributes, and conditions that are not
+// ValueConditions or whose name is not one of MetadataKeys are evaluated on every request.
+type CacheOptions struct {
+	TTL          time.Duration
+	MaxSize      int
+	MetadataKeys []string
+}
+
+type cacheEntry struct {
+	key      string
+	decision *Decision
+	expires  time.Time
+}
+
+type decisionCache struct {
+	ttl     time.Duration
+	size    int
+	keys    []string
+	gen     uint64
+	entries map[string]*list.Element
+	lru     *list.List
+	mu      sync.Mutex
+}
+
+func newDecisionCache(o CacheOptions) *decisionCache {
+	if o.TTL <= 0 {
+		o.TTL = DefaultCacheTTL
+	}
+
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultCacheSize
+	}
+
+	return &decisionCache{
+		ttl:     o.TTL,
+		size:    o.MaxSize,
+		keys:    o.MetadataKeys,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// key returns the cache key of r holding the resolved roles.
+func (c *decisionCache) key(r *Request, roles []string) string {
+	var sb strings.Builder
+
+	sorted := append([]string{}, roles...)
+	sort.Strings(sorted)
+
+	fmt.Fprintf(&sb, "%q|%q|%q|%q|%q|%q", r.Resource, r.Action, r.Role, r.Scope, r.SubjectID(), sorted)
+
+	meta := r.Metadata()
+	for _, k := range c.keys {
+		fmt.Fprintf(&sb, "|%q=%#v", k, meta[k])
+	}
+
+	return sb.String()
+}
+
+// hasKey evaluates true when the metadata key k is part of the cache key.
+func (c *decisionCache) hasKey(k string) bool {
+	for _, ck := range c.keys {
+		if ck == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generation returns a token identifying the current policy set. Decisions computed from policies loaded
+// under an older generation are not stored.
+func (c *decisionCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+func (c *decisionCache) get(key string) (*Decision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	ent := el.Value.(*cacheEntry)
+	if time.Now().After(ent.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	d := *ent.decision
+
+	return &d, true
+}
+
+func (c *decisionCache) put(key string, gen uint64, d *Decision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	cd := *d
+	ent := &cacheEntry{
+		key:      key,
+		decision: &cd,
+		expires:  time.Now().Add(c.ttl),
+	}
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = ent
+		c.lru.MoveToFront(el)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(ent)
+
+	for c.lru.Len() > c.size {
+		last := c.lru.Back()
+		c.lru.Remove(last)
+		delete(c.entries, last.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate drops every cached decision after a policy change.
+func (c *decisionCache) invalidate(PolicyChange, string) {
+	c.clear()
+}
+
+// invalidateRoles drops every cached decision after a role change.
+func (c *decisionCache) invalidateRoles(RoleChange, string) {
+	c.clear()
+}
+
+// clear drops every cached decision and starts a new generation.
+func (c *decisionCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
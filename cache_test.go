@@ -0,0 +1,49 @@
+package redtape
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecisionCache(t *testing.T) {
+	allow := &Decision{Effect: PolicyEffectAllow}
+
+	tests := []struct {
+		name    string
+		opts    CacheOptions
+		keys    []string
+		wait    time.Duration
+		lookup  string
+		expects bool
+	}{
+		{"hit", CacheOptions{}, []string{"a"}, 0, "a", true},
+		{"miss", CacheOptions{}, []string{"a"}, 0, "b", false},
+		{"evicted", CacheOptions{MaxSize: 2}, []string{"a", "b", "c"}, 0, "a", false},
+		{"retained", CacheOptions{MaxSize: 2}, []string{"a", "b", "c"}, 0, "c", true},
+		{"expired", CacheOptions{TTL: time.Millisecond}, []string{"a"}, 5 * time.Millisecond, "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDecisionCache(tt.opts)
+			for _, k := range tt.keys {
+				c.put(k, c.generation(), allow)
+			}
+
+			time.Sleep(tt.wait)
+
+			if _, ok := c.get(tt.lookup); ok != tt.expects {
+				t.Errorf("get(%q) = %v, want %v", tt.lookup, ok, tt.expects)
+			}
+		})
+	}
+
+	c := newDecisionCache(CacheOptions{})
+	gen := c.generation()
+	c.invalidate(PolicyCreated, "p")
+	c.put("a", gen, allow)
+
+	if _, ok := c.get("a"); ok {
+		t.Error("decision computed before invalidation was cached")
+	}
+}
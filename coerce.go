@@ -0,0 +1,259 @@
+package redtape
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// decodeCondition decodes opts into the Condition c. Options are matched to fields by their mapstructure tag,
+// falling back to their json tag and then to their name, and weakly typed input is accepted, such as numbers
+// given as strings. Strings are decoded into time.Time fields as RFC 3339 timestamps and into time.Duration
+// fields as Go durations.
+func decodeCondition(opts map[string]interface{}, c Condition) error {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           c,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeHookFunc(time.RFC3339),
+			mapstructure.StringToTimeDurationHookFunc(),
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	return dec.Decode(jsonTagOptions(opts, c))
+}
+
+// jsonTagOptions returns opts with the keys naming the json tag of a field of c without a mapstructure tag
+// renamed to the field name, which mapstructure matches without a tag.
+func jsonTagOptions(opts map[string]interface{}, c interface{}) map[string]interface{} {
+	t := reflect.TypeOf(c)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return opts
+	}
+
+	res := make(map[string]interface{}, len(opts))
+	for k, v := range opts {
+		res[k] = v
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("mapstructure") != "" {
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || strings.EqualFold(name, f.Name) {
+			continue
+		}
+
+		if v, ok := res[name]; ok {
+			delete(res, name)
+			res[f.Name] = v
+		}
+	}
+
+	return res
+}
+
+// toString coerces metadata values to a string. Numbers and booleans are formatted, other values must be
+// strings, byte slices or fmt.Stringers.
+func toString(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case json.Number:
+		return x.String(), true
+	case fmt.Stringer:
+		return x.String(), true
+	}
+
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+
+	return "", false
+}
+
+// decimalPattern matches plain decimal numbers without exponents, leading zeros or surrounding space.
+var decimalPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// toFloat coerces metadata values to a finite float64. Any integer or float type, json.Number and strings
+// holding plain decimal numbers like "-12.5" are accepted. NaN and infinite values are rejected.
+func toFloat(v interface{}) (float64, bool) {
+	var (
+		f   float64
+		err error
+	)
+
+	switch x := v.(type) {
+	case float64:
+		f = x
+	case float32:
+		f = float64(x)
+	case int:
+		f = float64(x)
+	case int8:
+		f = float64(x)
+	case int16:
+		f = float64(x)
+	case int32:
+		f = float64(x)
+	case int64:
+		f = float64(x)
+	case uint:
+		f = float64(x)
+	case uint8:
+		f = float64(x)
+	case uint16:
+		f = float64(x)
+	case uint32:
+		f = float64(x)
+	case uint64:
+		f = float64(x)
+	case json.Number:
+		f, err = x.Float64()
+	case string:
+		if !decimalPattern.MatchString(x) {
+			return 0, false
+		}
+
+		f, err = strconv.ParseFloat(x, 64)
+	default:
+		return 0, false
+	}
+
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+
+	return f, true
+}
+
+// toTime coerces metadata values to a time.Time. RFC 3339 timestamps, dates formatted as 2006-01-02 and
+// numbers holding Unix seconds are accepted.
+func toTime(v interface{}) (time.Time, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case *time.Time:
+		if x == nil {
+			return time.Time{}, false
+		}
+
+		return *x, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, x); err == nil {
+				return t, true
+			}
+		}
+
+		return time.Time{}, false
+	}
+
+	if f, ok := toFloat(v); ok {
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*float64(time.Second))), true
+	}
+
+	return time.Time{}, false
+}
+
+// toList coerces metadata values to a list. Slices of common element types are accepted and any other value
+// is a list of one element.
+func toList(v interface{}) []interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return x
+	case []string:
+		l := make([]interface{}, len(x))
+		for i, s := range x {
+			l[i] = s
+		}
+
+		return l
+	case []int:
+		l := make([]interface{}, len(x))
+		for i, n := range x {
+			l[i] = n
+		}
+
+		return l
+	case []int64:
+		l := make([]interface{}, len(x))
+		for i, n := range x {
+			l[i] = n
+		}
+
+		return l
+	case []float64:
+		l := make([]interface{}, len(x))
+		for i, f := range x {
+			l[i] = f
+		}
+
+		return l
+	case []bool:
+		l := make([]interface{}, len(x))
+		for i, b := range x {
+			l[i] = b
+		}
+
+		return l
+	default:
+		return []interface{}{v}
+	}
+}
+
+// isNumber evaluates true when v holds an integer or float type or a json.Number.
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// valuesEqual compares two metadata values. Values are compared numerically when at least one of them is a
+// number and the other coerces to one, and by their string form otherwise, so "007" and "7" differ.
+func valuesEqual(a, b interface{}) bool {
+	if isNumber(a) || isNumber(b) {
+		fa, aok := toFloat(a)
+		fb, bok := toFloat(b)
+
+		if aok && bok {
+			return fa == fb
+		}
+	}
+
+	sa, ok := toString(a)
+	if !ok {
+		return false
+	}
+
+	sb, ok := toString(b)
+
+	return ok && sa == sb
+}
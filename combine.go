@@ -0,0 +1,115 @@
+package redtape
+
+import "sort"
+
+// CombiningAlgorithm reduces the policies matching a request to a single Decision. Matched policies are
+// provided in evaluation order and def is the effect applied when no matched policy decides the request.
+type CombiningAlgorithm func(matched []Policy, def PolicyEffect) *Decision
+
+// DenyOverrides denies the request when any matched policy denies it. Otherwise a matched allow policy
+// permits the request and def is applied when nothing matched.
+func DenyOverrides(matched []Policy, def PolicyEffect) *Decision {
+	if p := firstWithEffect(matched, PolicyEffectDeny); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	if p := firstWithEffect(matched, PolicyEffectAllow); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	return newImplicitDecision(matched, def)
+}
+
+// PermitOverrides allows the request when any matched policy allows it. Otherwise a matched deny policy
+// denies the request and def is applied when nothing matched.
+func PermitOverrides(matched []Policy, def PolicyEffect) *Decision {
+	if p := firstWithEffect(matched, PolicyEffectAllow); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	if p := firstWithEffect(matched, PolicyEffectDeny); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	return newImplicitDecision(matched, def)
+}
+
+// FirstApplicable applies the effect of the matched policy with the highest priority. Policies sharing a
+// priority keep their evaluation order. When nothing matched, def is applied.
+func FirstApplicable(matched []Policy, def PolicyEffect) *Decision {
+	if len(matched) == 0 {
+		return newImplicitDecision(matched, def)
+	}
+
+	ordered := make([]Policy, len(matched))
+	copy(ordered, matched)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority() > ordered[j].Priority()
+	})
+
+	return newExplicitDecision(matched, ordered[0])
+}
+
+// DenyUnlessPermit allows the request only when a matched policy allows it and denies it in every other
+// case, ignoring def.
+func DenyUnlessPermit(matched []Policy, _ PolicyEffect) *Decision {
+	if p := firstWithEffect(matched, PolicyEffectAllow); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	if p := firstWithEffect(matched, PolicyEffectDeny); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	return newImplicitDecision(matched, PolicyEffectDeny)
+}
+
+// PermitUnlessDeny denies the request only when a matched policy denies it and allows it in every other
+// case, ignoring def.
+func PermitUnlessDeny(matched []Policy, _ PolicyEffect) *Decision {
+	if p := firstWithEffect(matched, PolicyEffectDeny); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	if p := firstWithEffect(matched, PolicyEffectAllow); p != nil {
+		return newExplicitDecision(matched, p)
+	}
+
+	return newImplicitDecision(matched, PolicyEffectAllow)
+}
+
+func firstWithEffect(pols []Policy, effect PolicyEffect) Policy {
+	for _, p := range pols {
+		if p.Effect() == effect {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func newExplicitDecision(matched []Policy, p Policy) *Decision {
+	return &Decision{
+		Effect:   p.Effect(),
+		Explicit: true,
+		Matched:  policyIDs(matched),
+		Policy:   p,
+	}
+}
+
+func newImplicitDecision(matched []Policy, effect PolicyEffect) *Decision {
+	return &Decision{
+		Effect:  effect,
+		Matched: policyIDs(matched),
+	}
+}
+
+func policyIDs(pols []Policy) []string {
+	ids := make([]string, 0, len(pols))
+	for _, p := range pols {
+		ids = append(ids, p.ID())
+	}
+
+	return ids
+}
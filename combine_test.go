@@ -0,0 +1,44 @@
+package redtape
+
+import "testing"
+
+func TestCombiningAlgorithms(t *testing.T) {
+	allow := MustNewPolicy(PolicyID("allow"), PolicyAllow())
+	deny := MustNewPolicy(PolicyID("deny"), PolicyDeny())
+	exception := MustNewPolicy(PolicyID("exception"), PolicyAllow(), SetPriority(10))
+
+	tests := []struct {
+		name     string
+		algo     CombiningAlgorithm
+		matched  []Policy
+		def      PolicyEffect
+		want     PolicyEffect
+		explicit bool
+		policy   string
+	}{
+		{"deny_overrides", DenyOverrides, []Policy{allow, deny}, PolicyEffectAllow, PolicyEffectDeny, true, "deny"},
+		{"deny_overrides_default", DenyOverrides, nil, PolicyEffectAllow, PolicyEffectAllow, false, ""},
+		{"permit_overrides", PermitOverrides, []Policy{deny, allow}, PolicyEffectDeny, PolicyEffectAllow, true, "allow"},
+		{"permit_overrides_deny", PermitOverrides, []Policy{deny}, PolicyEffectAllow, PolicyEffectDeny, true, "deny"},
+		{"first_applicable", FirstApplicable, []Policy{deny, exception}, PolicyEffectDeny, PolicyEffectAllow, true, "exception"},
+		{"first_applicable_order", FirstApplicable, []Policy{deny, allow}, PolicyEffectAllow, PolicyEffectDeny, true, "deny"},
+		{"deny_unless_permit", DenyUnlessPermit, nil, PolicyEffectAllow, PolicyEffectDeny, false, ""},
+		{"permit_unless_deny", PermitUnlessDeny, nil, PolicyEffectDeny, PolicyEffectAllow, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.algo(tt.matched, tt.def)
+			if d.Effect != tt.want || d.Explicit != tt.explicit {
+				t.Errorf("effect = %s explicit = %v, want %s explicit = %v", d.Effect, d.Explicit, tt.want, tt.explicit)
+			}
+
+			if d.Policy != nil && d.Policy.ID() != tt.policy || d.Policy == nil && tt.policy != "" {
+				t.Errorf("deciding policy = %v, want %s", d.Policy, tt.policy)
+			}
+
+			if len(d.Matched) != len(tt.matched) {
+				t.Errorf("matched = %v, want %d policies", d.Matched, len(tt.matched))
+			}
+		})
+	}
+}
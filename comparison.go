@@ -0,0 +1,489 @@
+package redtape
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// StringEqualsCondition matches a string value to the preconfigured Value.
+type StringEqualsCondition struct {
+	Value      string `json:"value"`
+	IgnoreCase bool   `json:"ignore_case"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *StringEqualsCondition) Name() string {
+	return "string_equals"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *StringEqualsCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a string, equals Value.
+func (c *StringEqualsCondition) Meets(val interface{}, _ *Request) bool {
+	s, ok := toString(val)
+
+	return ok && stringsEqual(s, c.Value, c.IgnoreCase)
+}
+
+func stringsEqual(a, b string, ignoreCase bool) bool {
+	if ignoreCase {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+// StringInCondition matches a string value to any of the preconfigured Values.
+type StringInCondition struct {
+	Values     []string `json:"values"`
+	IgnoreCase bool     `json:"ignore_case"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *StringInCondition) Name() string {
+	return "string_in"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *StringInCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a string, equals one of Values.
+func (c *StringInCondition) Meets(val interface{}, _ *Request) bool {
+	s, ok := toString(val)
+	if !ok {
+		return false
+	}
+
+	for _, v := range c.Values {
+		if stringsEqual(s, v, c.IgnoreCase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StringPrefixCondition matches string values starting with Prefix.
+type StringPrefixCondition struct {
+	Prefix string `json:"prefix"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *StringPrefixCondition) Name() string {
+	return "string_prefix"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *StringPrefixCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a string, starts with Prefix.
+func (c *StringPrefixCondition) Meets(val interface{}, _ *Request) bool {
+	s, ok := toString(val)
+
+	return ok && strings.HasPrefix(s, c.Prefix)
+}
+
+// StringSuffixCondition matches string values ending with Suffix.
+type StringSuffixCondition struct {
+	Suffix string `json:"suffix"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *StringSuffixCondition) Name() string {
+	return "string_suffix"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *StringSuffixCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a string, ends with Suffix.
+func (c *StringSuffixCondition) Meets(val interface{}, _ *Request) bool {
+	s, ok := toString(val)
+
+	return ok && strings.HasSuffix(s, c.Suffix)
+}
+
+// StringRegexCondition matches string values against the regular expression Pattern, which is compiled when
+// the condition is built.
+type StringRegexCondition struct {
+	Pattern string `json:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// Name fulfills the Name method of Condition.
+func (c *StringRegexCondition) Name() string {
+	return "string_regex"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *StringRegexCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a string, matches Pattern.
+func (c *StringRegexCondition) Meets(val interface{}, _ *Request) bool {
+	if c.re == nil {
+		return false
+	}
+
+	s, ok := toString(val)
+
+	return ok && c.re.MatchString(s)
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, compiling the "pattern" option.
+func (c *StringRegexCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	if err := decodeCondition(opts, c); err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return err
+	}
+
+	c.re = re
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *StringRegexCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"pattern": c.Pattern}
+}
+
+// NumericLessThanCondition matches numeric values below Value.
+type NumericLessThanCondition struct {
+	Value float64 `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NumericLessThanCondition) Name() string {
+	return "numeric_lt"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *NumericLessThanCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a number, is less than Value.
+func (c *NumericLessThanCondition) Meets(val interface{}, _ *Request) bool {
+	f, ok := toFloat(val)
+
+	return ok && f < c.Value
+}
+
+// NumericLessThanEqualsCondition matches numeric values at or below Value.
+type NumericLessThanEqualsCondition struct {
+	Value float64 `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NumericLessThanEqualsCondition) Name() string {
+	return "numeric_lte"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *NumericLessThanEqualsCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a number, is less than or equal to Value.
+func (c *NumericLessThanEqualsCondition) Meets(val interface{}, _ *Request) bool {
+	f, ok := toFloat(val)
+
+	return ok && f <= c.Value
+}
+
+// NumericGreaterThanCondition matches numeric values above Value.
+type NumericGreaterThanCondition struct {
+	Value float64 `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NumericGreaterThanCondition) Name() string {
+	return "numeric_gt"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *NumericGreaterThanCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a number, is greater than Value.
+func (c *NumericGreaterThanCondition) Meets(val interface{}, _ *Request) bool {
+	f, ok := toFloat(val)
+
+	return ok && f > c.Value
+}
+
+// NumericGreaterThanEqualsCondition matches numeric values at or above Value.
+type NumericGreaterThanEqualsCondition struct {
+	Value float64 `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NumericGreaterThanEqualsCondition) Name() string {
+	return "numeric_gte"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *NumericGreaterThanEqualsCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a number, is greater than or equal to Value.
+func (c *NumericGreaterThanEqualsCondition) Meets(val interface{}, _ *Request) bool {
+	f, ok := toFloat(val)
+
+	return ok && f >= c.Value
+}
+
+// NumericBetweenCondition matches numeric values within the inclusive range of Min and Max.
+type NumericBetweenCondition struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NumericBetweenCondition) Name() string {
+	return "numeric_between"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *NumericBetweenCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a number, is between Min and Max inclusive.
+func (c *NumericBetweenCondition) Meets(val interface{}, _ *Request) bool {
+	f, ok := toFloat(val)
+
+	return ok && f >= c.Min && f <= c.Max
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, rejecting a Min above Max.
+func (c *NumericBetweenCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	if err := decodeCondition(opts, c); err != nil {
+		return err
+	}
+
+	if c.Min > c.Max {
+		return fmt.Errorf("condition %s min %v is above max %v", c.Name(), c.Min, c.Max)
+	}
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *NumericBetweenCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"min": c.Min, "max": c.Max}
+}
+
+// TimeBeforeCondition matches times before Value.
+type TimeBeforeCondition struct {
+	Value time.Time `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *TimeBeforeCondition) Name() string {
+	return "time_before"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *TimeBeforeCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a time, is before Value.
+func (c *TimeBeforeCondition) Meets(val interface{}, _ *Request) bool {
+	t, ok := toTime(val)
+
+	return ok && t.Before(c.Value)
+}
+
+// TimeAfterCondition matches times after Value.
+type TimeAfterCondition struct {
+	Value time.Time `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *TimeAfterCondition) Name() string {
+	return "time_after"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *TimeAfterCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a time, is after Value.
+func (c *TimeAfterCondition) Meets(val interface{}, _ *Request) bool {
+	t, ok := toTime(val)
+
+	return ok && t.After(c.Value)
+}
+
+// TimeBetweenCondition matches times from Start up to, but excluding, End.
+type TimeBetweenCondition struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *TimeBetweenCondition) Name() string {
+	return "time_between"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *TimeBetweenCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a time, is within Start and End.
+func (c *TimeBetweenCondition) Meets(val interface{}, _ *Request) bool {
+	t, ok := toTime(val)
+
+	return ok && !t.Before(c.Start) && t.Before(c.End)
+}
+
+// DayOfWeekCondition matches times falling on one of Days, given as English weekday names like "monday" or
+// their three letter abbreviations. Times are evaluated in their own location.
+type DayOfWeekCondition struct {
+	Days []string `json:"days"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *DayOfWeekCondition) Name() string {
+	return "day_of_week"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *DayOfWeekCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a time, falls on one of Days.
+func (c *DayOfWeekCondition) Meets(val interface{}, _ *Request) bool {
+	t, ok := toTime(val)
+	if !ok {
+		return false
+	}
+
+	day := strings.ToLower(t.Weekday().String())
+	for _, d := range c.Days {
+		d = strings.ToLower(d)
+		if d == day || (len(d) == 3 && strings.HasPrefix(day, d)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, requiring at least one day and rejecting
+// unknown day names.
+func (c *DayOfWeekCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	if err := decodeCondition(opts, c); err != nil {
+		return err
+	}
+
+	if len(c.Days) == 0 {
+		return fmt.Errorf("condition %s requires at least one day", c.Name())
+	}
+
+	for _, d := range c.Days {
+		if !isWeekday(d) {
+			return fmt.Errorf("condition %s has unknown day %q", c.Name(), d)
+		}
+	}
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *DayOfWeekCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"days": c.Days}
+}
+
+// isWeekday evaluates true for English weekday names and their three letter abbreviations in any case.
+func isWeekday(s string) bool {
+	s = strings.ToLower(s)
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		day := strings.ToLower(d.String())
+		if s == day || s == day[:3] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListContainsCondition matches lists holding Value.
+type ListContainsCondition struct {
+	Value interface{} `json:"value"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *ListContainsCondition) Name() string {
+	return "list_contains"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *ListContainsCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a list, holds an element equal to Value. Elements are compared
+// numerically when either side is a number and by their string form otherwise.
+func (c *ListContainsCondition) Meets(val interface{}, _ *Request) bool {
+	for _, v := range toList(val) {
+		if valuesEqual(v, c.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetIntersectsCondition matches lists sharing at least one element with Values.
+type SetIntersectsCondition struct {
+	Values []interface{} `json:"values"`
+}
+
+// Name fulfills the Name method of Condition.
+func (c *SetIntersectsCondition) Name() string {
+	return "set_intersects"
+}
+
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *SetIntersectsCondition) ValueOnly() bool {
+	return true
+}
+
+// Meets evaluates true when val, coerced to a list, holds an element equal to one of Values.
+func (c *SetIntersectsCondition) Meets(val interface{}, _ *Request) bool {
+	for _, v := range toList(val) {
+		for _, w := range c.Values {
+			if valuesEqual(v, w) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package redtape
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComparisonConditions(t *testing.T) {
+	monday := time.Date(2021, time.July, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		typ  string
+		opts map[string]interface{}
+		val  interface{}
+		want bool
+	}{
+		{"string_equals", "string_equals", map[string]interface{}{"value": "eu"}, "eu", true},
+		{"string_equals_case", "string_equals", map[string]interface{}{"value": "EU", "ignore_case": true}, "eu", true},
+		{"string_equals_number", "string_equals", map[string]interface{}{"value": "42"}, 42, true},
+		{"string_equals_mismatch", "string_equals", map[string]interface{}{"value": "eu"}, "us", false},
+		{"string_equals_nil", "string_equals", map[string]interface{}{"value": ""}, nil, false},
+		{"string_in", "string_in", map[string]interface{}{"values": []interface{}{"eu", "uk"}}, "uk", true},
+		{"string_in_mismatch", "string_in", map[string]interface{}{"values": []interface{}{"eu", "uk"}}, "us", false},
+		{"string_prefix", "string_prefix", map[string]interface{}{"prefix": "/api/"}, "/api/users", true},
+		{"string_suffix", "string_suffix", map[string]interface{}{"suffix": "@example.com"}, "bob@example.org", false},
+		{"string_regex", "string_regex", map[string]interface{}{"pattern": `^\d{3}-\d{4}$`}, "555-1234", true},
+		{"string_regex_bytes", "string_regex", map[string]interface{}{"pattern": `^ab`}, []byte("abc"), true},
+		{"numeric_lt", "numeric_lt", map[string]interface{}{"value": 1000}, 999.5, true},
+		{"numeric_lt_equal", "numeric_lt", map[string]interface{}{"value": 1000}, 1000, false},
+		{"numeric_lte_string", "numeric_lte", map[string]interface{}{"value": "1000"}, "1000", true},
+		{"numeric_gt_json", "numeric_gt", map[string]interface{}{"value": 10}, json.Number("10.5"), true},
+		{"numeric_gte_uint", "numeric_gte", map[string]interface{}{"value": 10}, uint8(9), false},
+		{"numeric_between", "numeric_between", map[string]interface{}{"min": 1, "max": 5}, int64(5), true},
+		{"numeric_between_outside", "numeric_between", map[string]interface{}{"min": 1, "max": 5}, 0, false},
+		{"numeric_invalid", "numeric_gt", map[string]interface{}{"value": 1}, "many", false},
+		{"numeric_hex", "numeric_gt", map[string]interface{}{"value": 1}, "0x10", false},
+		{"numeric_exponent", "numeric_gt", map[string]interface{}{"value": 1}, "1e3", false},
+		{"numeric_leading_zero", "numeric_gt", map[string]interface{}{"value": 1}, "010", false},
+		{"numeric_infinity_string", "numeric_gt", map[string]interface{}{"value": 1}, "Inf", false},
+		{"numeric_infinity", "numeric_gt", map[string]interface{}{"value": 1}, math.Inf(1), false},
+		{"numeric_nan", "numeric_lt", map[string]interface{}{"value": 1}, math.NaN(), false},
+		{"numeric_negative_string", "numeric_lt", map[string]interface{}{"value": 1}, "-0.5", true},
+		{"time_before", "time_before", map[string]interface{}{"value": "2021-07-06T00:00:00Z"}, monday, true},
+		{"time_before_string", "time_before", map[string]interface{}{"value": "2021-07-06T00:00:00Z"}, "2021-07-07", false},
+		{"time_after_unix", "time_after", map[string]interface{}{"value": "2021-07-01T00:00:00Z"}, monday.Unix(), true},
+		{"time_after_nil", "time_after", map[string]interface{}{"value": "2021-07-01T00:00:00Z"}, nil, false},
+		{"time_between", "time_between", map[string]interface{}{"start": "2021-07-05T09:00:00Z", "end": "2021-07-05T17:00:00Z"}, monday, true},
+		{"time_between_end", "time_between", map[string]interface{}{"start": "2021-07-05T09:00:00Z", "end": "2021-07-05T10:00:00Z"}, monday, false},
+		{"time_invalid", "time_before", map[string]interface{}{"value": "2021-07-06T00:00:00Z"}, "yesterday", false},
+		{"day_of_week", "day_of_week", map[string]interface{}{"days": []interface{}{"Monday", "tue"}}, monday, true},
+		{"day_of_week_abbrev", "day_of_week", map[string]interface{}{"days": []interface{}{"sat", "mon"}}, monday, true},
+		{"day_of_week_nil", "day_of_week", map[string]interface{}{"days": []interface{}{"monday"}}, nil, false},
+		{"day_of_week_mismatch", "day_of_week", map[string]interface{}{"days": []interface{}{"saturday", "sunday"}}, monday, false},
+		{"list_contains", "list_contains", map[string]interface{}{"value": "admin"}, []string{"user", "admin"}, true},
+		{"list_contains_number", "list_contains", map[string]interface{}{"value": 2}, []interface{}{1.0, 2.0}, true},
+		{"list_contains_single", "list_contains", map[string]interface{}{"value": "admin"}, "admin", true},
+		{"list_contains_missing", "list_contains", map[string]interface{}{"value": "admin"}, []string{"user"}, false},
+		{"list_contains_nil", "list_contains", map[string]interface{}{"value": "admin"}, nil, false},
+		{"list_contains_numeric_strings", "list_contains", map[string]interface{}{"value": "7"}, []string{"007"}, false},
+		{"list_contains_padded_number", "list_contains", map[string]interface{}{"value": 7}, []string{"007"}, false},
+		{"list_contains_number_string", "list_contains", map[string]interface{}{"value": 7}, []string{"7"}, true},
+		{"set_intersects", "set_intersects", map[string]interface{}{"values": []interface{}{"eu", "uk"}}, []interface{}{"us", "uk"}, true},
+		{"set_intersects_numbers", "set_intersects", map[string]interface{}{"values": []interface{}{1, 2}}, []int{3, 2}, true},
+		{"set_intersects_numeric_strings", "set_intersects", map[string]interface{}{"values": []interface{}{"1e3", "07"}}, []string{"1000", "7"}, false},
+		{"set_intersects_disjoint", "set_intersects", map[string]interface{}{"values": []interface{}{"eu"}}, []string{"us"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conds, err := NewConditions([]ConditionOptions{{Name: "c", Type: tt.typ, Options: tt.opts}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := conds["c"].Meets(tt.val, nil); got != tt.want {
+				t.Errorf("Condition.Meets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComparisonConditionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  string
+		opts map[string]interface{}
+	}{
+		{"invalid_regex", "string_regex", map[string]interface{}{"pattern": "("}},
+		{"invalid_time", "time_before", map[string]interface{}{"value": "tomorrow"}},
+		{"invalid_number", "numeric_lt", map[string]interface{}{"value": "ten"}},
+		{"inverted_range", "numeric_between", map[string]interface{}{"min": 10, "max": 1}},
+		{"unknown_day", "day_of_week", map[string]interface{}{"days": []string{"monday", "funday"}}},
+		{"partial_day", "day_of_week", map[string]interface{}{"days": []string{"mo"}}},
+		{"no_days", "day_of_week", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConditions([]ConditionOptions{{Name: "c", Type: tt.typ, Options: tt.opts}}, nil); err == nil {
+				t.Errorf("NewConditions() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestComparisonConditionsOptions(t *testing.T) {
+	built := []ConditionOptions{
+		{Name: "amount", Type: "numeric_between", Options: map[string]interface{}{"min": 1, "max": 100}},
+		{Name: "email", Type: "string_regex", Options: map[string]interface{}{"pattern": "@example\\.com$"}},
+		{Name: "weekday", Type: "day_of_week", Options: map[string]interface{}{"days": []string{"Monday", "fri"}}},
+		{Name: "window", Type: "time_between", Options: map[string]interface{}{
+			"start": "2021-07-05T09:00:00Z",
+			"end":   "2021-07-05T17:00:00Z",
+		}},
+	}
+
+	conds, err := NewConditions(built, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(conds.Options())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var opts []ConditionOptions
+	if err := json.Unmarshal(b, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt, err := NewConditions(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rebuilt.Options(), conds.Options()) {
+		t.Errorf("Conditions.Options() = %v, want %v", rebuilt.Options(), conds.Options())
+	}
+
+	if !rebuilt["window"].Meets("2021-07-05T12:00:00Z", nil) {
+		t.Errorf("rebuilt time_between condition did not match")
+	}
+}
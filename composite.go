@@ -0,0 +1,175 @@
+package redtape
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ConditionCodec is an optional interface for Conditions whose options cannot be mapped directly onto their
+// fields, such as conditions nesting other conditions. NewConditions calls DecodeOptions with the registry
+// in use and Conditions#Options serializes the condition through EncodeOptions.
+type ConditionCodec interface {
+	DecodeOptions(opts map[string]interface{}, reg ConditionRegistry) error
+	EncodeOptions() map[string]interface{}
+}
+
+// meetsNested evaluates c against the RequestMetadata value stored under name.
+func meetsNested(name string, c Condition, r *Request) bool {
+	var val interface{}
+	if r != nil {
+		val = r.Metadata()[name]
+	}
+
+	return c.Meets(val, r)
+}
+
+// decodeNested builds the Conditions held by key in opts through reg for the condition named name. The list
+// must hold at least one condition and nested condition names must be unique.
+func decodeNested(name string, opts map[string]interface{}, key string, reg ConditionRegistry) (Conditions, error) {
+	var copts []ConditionOptions
+	if err := mapstructure.Decode(opts[key], &copts); err != nil {
+		return nil, err
+	}
+
+	if len(copts) == 0 {
+		return nil, fmt.Errorf("condition %s requires at least one nested condition", name)
+	}
+
+	seen := make(map[string]bool, len(copts))
+	for _, co := range copts {
+		if seen[co.Name] {
+			return nil, fmt.Errorf("condition %s has duplicate nested condition %s", name, co.Name)
+		}
+
+		seen[co.Name] = true
+	}
+
+	return NewConditions(copts, reg)
+}
+
+// AllOfCondition passes when every nested condition passes.
+type AllOfCondition struct {
+	Conditions Conditions
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AllOfCondition) Name() string {
+	return "all_of"
+}
+
+// Meets evaluates true when all nested Conditions are met by the request metadata stored under their names.
+func (c *AllOfCondition) Meets(_ interface{}, r *Request) bool {
+	for _, name := range c.Conditions.Names() {
+		if !meetsNested(name, c.Conditions[name], r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, decoding the "conditions" list.
+func (c *AllOfCondition) DecodeOptions(opts map[string]interface{}, reg ConditionRegistry) error {
+	conds, err := decodeNested(c.Name(), opts, "conditions", reg)
+	if err != nil {
+		return err
+	}
+
+	c.Conditions = conds
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AllOfCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"conditions": c.Conditions.Options()}
+}
+
+// AnyOfCondition passes when at least one nested condition passes.
+type AnyOfCondition struct {
+	Conditions Conditions
+}
+
+// Name fulfills the Name method of Condition.
+func (c *AnyOfCondition) Name() string {
+	return "any_of"
+}
+
+// Meets evaluates true when any nested Condition is met by the request metadata stored under its name.
+func (c *AnyOfCondition) Meets(_ interface{}, r *Request) bool {
+	for _, name := range c.Conditions.Names() {
+		if meetsNested(name, c.Conditions[name], r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, decoding the "conditions" list.
+func (c *AnyOfCondition) DecodeOptions(opts map[string]interface{}, reg ConditionRegistry) error {
+	conds, err := decodeNested(c.Name(), opts, "conditions", reg)
+	if err != nil {
+		return err
+	}
+
+	c.Conditions = conds
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *AnyOfCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"conditions": c.Conditions.Options()}
+}
+
+// NotCondition negates a single nested condition.
+type NotCondition struct {
+	Conditions Conditions
+}
+
+// Name fulfills the Name method of Condition.
+func (c *NotCondition) Name() string {
+	return "not"
+}
+
+// Meets evaluates true when the nested Condition is not met by the request metadata stored under its name.
+func (c *NotCondition) Meets(_ interface{}, r *Request) bool {
+	for name, cond := range c.Conditions {
+		return !meetsNested(name, cond, r)
+	}
+
+	return false
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, decoding the single "condition".
+func (c *NotCondition) DecodeOptions(opts map[string]interface{}, reg ConditionRegistry) error {
+	var co ConditionOptions
+	if err := mapstructure.Decode(opts["condition"], &co); err != nil {
+		return err
+	}
+
+	if co.Type == "" {
+		return fmt.Errorf("condition %s requires a nested condition", c.Name())
+	}
+
+	conds, err := NewConditions([]ConditionOptions{co}, reg)
+	if err != nil {
+		return err
+	}
+
+	c.Conditions = conds
+
+	return nil
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *NotCondition) EncodeOptions() map[string]interface{} {
+	opts := c.Conditions.Options()
+	if len(opts) == 0 {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{"condition": opts[0]}
+}
@@ -0,0 +1,136 @@
+package redtape
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func jsonCompositeCond() []byte {
+	return []byte(`
+[
+	{
+		"name": "internal_or_mfa",
+		"type": "any_of",
+		"options": {
+			"conditions": [
+				{"name": "internal", "type": "bool", "options": {"value": true}},
+				{
+					"name": "mfa_unlocked",
+					"type": "all_of",
+					"options": {
+						"conditions": [
+							{"name": "mfa", "type": "bool", "options": {"value": true}},
+							{"name": "not_locked", "type": "not", "options": {"condition": {"name": "locked", "type": "bool", "options": {"value": true}}}}
+						]
+					}
+				}
+			]
+		}
+	}
+]
+`)
+}
+
+func TestCompositeConditions(t *testing.T) {
+	var opts []ConditionOptions
+	if err := json.Unmarshal(jsonCompositeCond(), &opts); err != nil {
+		t.Fatalf("failed to unmarshal options: %v", err)
+	}
+
+	conds, err := NewConditions(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		meta map[string]interface{}
+		want bool
+	}{
+		{"internal", map[string]interface{}{"internal": true}, true},
+		{"mfa", map[string]interface{}{"mfa": true}, true},
+		{"mfa_locked", map[string]interface{}{"mfa": true, "locked": true}, false},
+		{"internal_locked", map[string]interface{}{"internal": true, "locked": true}, true},
+		{"none", map[string]interface{}{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRequest("/", "read", "user", "", tt.meta)
+			if got := conds["internal_or_mfa"].Meets(nil, r); got != tt.want {
+				t.Errorf("Condition.Meets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompositeConditionsOptions(t *testing.T) {
+	built := []ConditionOptions{
+		{Name: "not_internal", Type: "not", Options: map[string]interface{}{
+			"condition": ConditionOptions{Name: "internal", Type: "bool", Options: map[string]interface{}{"value": true}},
+		}},
+		{Name: "mfa", Type: "all_of", Options: map[string]interface{}{
+			"conditions": []ConditionOptions{
+				{Name: "mfa", Type: "bool", Options: map[string]interface{}{"value": true}},
+			},
+		}},
+	}
+
+	var unm []ConditionOptions
+	if err := json.Unmarshal(jsonCompositeCond(), &unm); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []ConditionOptions
+		wantErr bool
+	}{
+		{"built", built, false},
+		{"unmarshalled", unm, false},
+		{"unknown_nested", []ConditionOptions{{Name: "any", Type: "any_of", Options: map[string]interface{}{
+			"conditions": []ConditionOptions{{Name: "x", Type: "unknown"}},
+		}}}, true},
+		{"not_without_condition", []ConditionOptions{{Name: "not", Type: "not"}}, true},
+		{"all_of_without_conditions", []ConditionOptions{{Name: "all", Type: "all_of"}}, true},
+		{"any_of_empty_conditions", []ConditionOptions{{Name: "any", Type: "any_of", Options: map[string]interface{}{
+			"conditions": []ConditionOptions{},
+		}}}, true},
+		{"duplicate_nested", []ConditionOptions{{Name: "all", Type: "all_of", Options: map[string]interface{}{
+			"conditions": []ConditionOptions{
+				{Name: "mfa", Type: "bool", Options: map[string]interface{}{"value": true}},
+				{Name: "mfa", Type: "bool", Options: map[string]interface{}{"value": false}},
+			},
+		}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conds, err := NewConditions(tt.opts, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			p := MustNewPolicy(SetPolicyOptions(PolicyOptions{ID: "composite", Conditions: conds.Options()}))
+
+			b, err := json.Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := UnmarshalPolicy(b, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got.Conditions(), conds) {
+				t.Errorf("round trip conditions = %#v, want %#v", got.Conditions(), conds)
+			}
+		})
+	}
+}
@@ -2,8 +2,9 @@ package redtape
 
 import (
 	"fmt"
+	"sort"
 
-	"github.com/mitchellh/mapstructure"
+	"github.com/fatih/structs"
 )
 
 // ConditionBuilder is a typed function that returns a Condition.
@@ -13,7 +14,9 @@ type ConditionBuilder func() Condition
 type ConditionRegistry map[string]ConditionBuilder
 
 // NewConditionRegistry returns a ConditionRegistry containing the default Conditions and accepts an array
-// of map[string]ConditionBuilder to add custom conditions to the set.
+// of map[string]ConditionBuilder to add custom conditions to the set. The defaults include the all_of, any_of
+// and not conditions nesting other conditions of the registry, the expr condition, the string, numeric, time
+// and list comparison conditions and the attribute conditions comparing request values by path.
 func NewConditionRegistry(conds ...map[string]ConditionBuilder) ConditionRegistry {
 	reg := ConditionRegistry{
 		new(BoolCondition).Name(): func() Condition {
@@ -22,6 +25,78 @@ func NewConditionRegistry(conds ...map[string]ConditionBuilder) ConditionRegistr
 		new(RoleEqualsCondition).Name(): func() Condition {
 			return new(RoleEqualsCondition)
 		},
+		new(AllOfCondition).Name(): func() Condition {
+			return new(AllOfCondition)
+		},
+		new(AnyOfCondition).Name(): func() Condition {
+			return new(AnyOfCondition)
+		},
+		new(NotCondition).Name(): func() Condition {
+			return new(NotCondition)
+		},
+		new(ExprCondition).Name(): func() Condition {
+			return new(ExprCondition)
+		},
+		new(StringEqualsCondition).Name(): func() Condition {
+			return new(StringEqualsCondition)
+		},
+		new(StringInCondition).Name(): func() Condition {
+			return new(StringInCondition)
+		},
+		new(StringPrefixCondition).Name(): func() Condition {
+			return new(StringPrefixCondition)
+		},
+		new(StringSuffixCondition).Name(): func() Condition {
+			return new(StringSuffixCondition)
+		},
+		new(StringRegexCondition).Name(): func() Condition {
+			return new(StringRegexCondition)
+		},
+		new(NumericLessThanCondition).Name(): func() Condition {
+			return new(NumericLessThanCondition)
+		},
+		new(NumericLessThanEqualsCondition).Name(): func() Condition {
+			return new(NumericLessThanEqualsCondition)
+		},
+		new(NumericGreaterThanCondition).Name(): func() Condition {
+			return new(NumericGreaterThanCondition)
+		},
+		new(NumericGreaterThanEqualsCondition).Name(): func() Condition {
+			return new(NumericGreaterThanEqualsCondition)
+		},
+		new(NumericBetweenCondition).Name(): func() Condition {
+			return new(NumericBetweenCondition)
+		},
+		new(TimeBeforeCondition).Name(): func() Condition {
+			return new(TimeBeforeCondition)
+		},
+		new(TimeAfterCondition).Name(): func() Condition {
+			return new(TimeAfterCondition)
+		},
+		new(TimeBetweenCondition).Name(): func() Condition {
+			return new(TimeBetweenCondition)
+		},
+		new(DayOfWeekCondition).Name(): func() Condition {
+			return new(DayOfWeekCondition)
+		},
+		new(ListContainsCondition).Name(): func() Condition {
+			return new(ListContainsCondition)
+		},
+		new(SetIntersectsCondition).Name(): func() Condition {
+			return new(SetIntersectsCondition)
+		},
+		new(AttributeEqualsCondition).Name(): func() Condition {
+			return new(AttributeEqualsCondition)
+		},
+		new(AttributeNotEqualsCondition).Name(): func() Condition {
+			return new(AttributeNotEqualsCondition)
+		},
+		new(AttributeInCondition).Name(): func() Condition {
+			return new(AttributeInCondition)
+		},
+		new(AttributeCompareCondition).Name(): func() Condition {
+			return new(AttributeCompareCondition)
+		},
 	}
 
 	for _, ce := range conds {
@@ -39,10 +114,60 @@ type Condition interface {
 	Meets(interface{}, *Request) bool
 }
 
+// ValueCondition is an optional interface for Conditions whose result depends only on the value passed to
+// Meets and on the request fields that are part of the decision cache key. Decisions of policies holding other
+// conditions are never cached, see CacheOptions.
+type ValueCondition interface {
+	ValueOnly() bool
+}
+
 // Conditions is a map of named Conditions.
 type Conditions map[string]Condition
 
+// Names returns the sorted names of the Conditions or nil when empty.
+func (c Conditions) Names() []string {
+	if len(c) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Options returns the ConditionOptions building the Conditions, sorted by name. Condition values are taken from
+// the exported fields of each Condition, keyed by their json tag, or from ConditionCodec#EncodeOptions.
+func (c Conditions) Options() []ConditionOptions {
+	structs.DefaultTagName = "json"
+
+	opts := make([]ConditionOptions, 0, len(c))
+	for _, name := range c.Names() {
+		opts = append(opts, ConditionOptions{
+			Name:    name,
+			Type:    c[name].Name(),
+			Options: conditionOptions(c[name]),
+		})
+	}
+
+	return opts
+}
+
+func conditionOptions(c Condition) map[string]interface{} {
+	if cc, ok := c.(ConditionCodec); ok {
+		return cc.EncodeOptions()
+	}
+
+	return structs.Map(c)
+}
+
 // NewConditions accepts an array of options and an optional ConditionRegistry and returns a Conditions map.
+// Options are decoded onto the fields of each Condition by their mapstructure tag, their json tag or their name
+// unless it implements ConditionCodec.
 func NewConditions(opts []ConditionOptions, reg ConditionRegistry) (Conditions, error) {
 	if reg == nil {
 		reg = NewConditionRegistry()
@@ -53,8 +178,12 @@ func NewConditions(opts []ConditionOptions, reg ConditionRegistry) (Conditions,
 	for _, co := range opts {
 		if cf, ok := reg[co.Type]; ok {
 			nc := cf()
-			if len(co.Options) > 0 {
-				if err := mapstructure.Decode(co.Options, &nc); err != nil {
+			if cc, ok := nc.(ConditionCodec); ok {
+				if err := cc.DecodeOptions(co.Options, reg); err != nil {
+					return nil, err
+				}
+			} else if len(co.Options) > 0 {
+				if err := decodeCondition(co.Options, nc); err != nil {
 					return nil, err
 				}
 			}
@@ -85,6 +214,11 @@ func (c *BoolCondition) Name() string {
 	return "bool"
 }
 
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *BoolCondition) ValueOnly() bool {
+	return true
+}
+
 // Meets evaluates whether parameter val matches the Condition Value.
 func (c *BoolCondition) Meets(val interface{}, _ *Request) bool {
 	v, ok := val.(bool)
@@ -100,6 +234,11 @@ func (c *RoleEqualsCondition) Name() string {
 	return "role_equals"
 }
 
+// ValueOnly fulfills the ValueOnly method of ValueCondition.
+func (c *RoleEqualsCondition) ValueOnly() bool {
+	return true
+}
+
 // Meets evaluates true when the role val matches Request#Role.
 func (c *RoleEqualsCondition) Meets(val interface{}, r *Request) bool {
 	switch v := val.(type) {
@@ -3,6 +3,7 @@ package redtape
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 )
@@ -78,3 +79,39 @@ func TestNewConditions(t *testing.T) {
 		})
 	}
 }
+
+type sessionCondition struct {
+	MaxAge     time.Duration `mapstructure:"max_age"`
+	RequireMFA bool          `mapstructure:"require_mfa" json:"mfa"`
+	Issuer     string        `json:"issuer"`
+	Audience   string
+}
+
+func (c *sessionCondition) Name() string {
+	return "session"
+}
+
+func (c *sessionCondition) Meets(interface{}, *Request) bool {
+	return true
+}
+
+func TestNewConditionsTags(t *testing.T) {
+	reg := NewConditionRegistry(map[string]ConditionBuilder{
+		"session": func() Condition { return new(sessionCondition) },
+	})
+
+	conds, err := NewConditions([]ConditionOptions{{Name: "s", Type: "session", Options: map[string]interface{}{
+		"max_age":     "15m",
+		"require_mfa": true,
+		"issuer":      "acme",
+		"audience":    "api",
+	}}}, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &sessionCondition{MaxAge: 15 * time.Minute, RequireMFA: true, Issuer: "acme", Audience: "api"}
+	if got := conds["s"]; *got.(*sessionCondition) != *want {
+		t.Errorf("NewConditions() = %+v, want %+v", got, want)
+	}
+}
@@ -17,6 +17,11 @@ func (c *IPAllowCondition) Name() string {
 	return "ip_allow"
 }
 
+// ValueOnly fulfills the ValueOnly method of redtape.ValueCondition.
+func (c *IPAllowCondition) ValueOnly() bool {
+	return true
+}
+
 // Meets evaluates true when the network address in val is contained within
 // one of the CIDR ranges of IPAllowCondition#Networks.
 func (c *IPAllowCondition) Meets(val interface{}, _ *redtape.Request) bool {
@@ -39,6 +44,11 @@ func (c *IPDenyCondition) Name() string {
 	return "ip_deny"
 }
 
+// ValueOnly fulfills the ValueOnly method of redtape.ValueCondition.
+func (c *IPDenyCondition) ValueOnly() bool {
+	return true
+}
+
 // Meets evaluates true when the network address in val is contained within
 // one of the CIDR ranges of IPDenyCondition#Networks.
 func (c *IPDenyCondition) Meets(val interface{}, _ *redtape.Request) bool {
@@ -65,3 +65,60 @@ func TestIPConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestIPConditionsPolicy(t *testing.T) {
+	reg := redtape.NewConditionRegistry(map[string]redtape.ConditionBuilder{
+		new(IPAllowCondition).Name(): func() redtape.Condition {
+			return new(IPAllowCondition)
+		},
+	})
+
+	b := []byte(`{
+		"id": "office_only",
+		"roles": [{"id": "staff"}],
+		"resources": ["/reports"],
+		"actions": ["read"],
+		"scopes": ["*"],
+		"effect": "allow",
+		"conditions": [
+			{"name": "remote_addr", "type": "ip_allow", "options": {"networks": ["192.168.1.0/24"]}}
+		]
+	}`)
+
+	if _, err := redtape.UnmarshalPolicy(b, nil); err == nil {
+		t.Fatal("UnmarshalPolicy() without ip conditions registered should fail")
+	}
+
+	p, err := redtape.UnmarshalPolicy(b, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pm := redtape.NewManager()
+	if err := pm.Create(p); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := redtape.NewEnforcerWithOptions(pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		addr string
+		want bool
+	}{
+		{"office", "192.168.1.20", true},
+		{"remote", "10.0.0.5", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := redtape.NewRequest("/reports", "read", "staff", "", map[string]interface{}{"remote_addr": tt.addr})
+			if got := e.Enforce(req) == nil; got != tt.want {
+				t.Errorf("Enforce() allowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
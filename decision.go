@@ -0,0 +1,67 @@
+package redtape
+
+import "errors"
+
+// Decision describes the outcome of an Enforcer evaluating a Request.
+// Effect holds the final PolicyEffect. Explicit is true when the effect was set by a matching policy
+// rather than the fallback effect. Matched holds the IDs of every policy matching the request, and Policy
+// is the policy that decided the effect or nil when no policy was applied. Trace is only set when the
+// Enforcer runs in explain mode. Shadow is only set when at least one shadow policy matched the request.
+// Obligations and Advice are collected from the matched policies sharing the final effect. Unfulfilled holds
+// the obligations no handler fulfilled, in which case the Decision is denied. DanglingRoles holds the role
+// references of candidate policies missing from the RoleManager of the Enforcer. Violation is set when the
+// request was denied for activating roles violating a separation of duty constraint.
+type Decision struct {
+	Effect   PolicyEffect    `json:"effect"`
+	Explicit bool            `json:"explicit"`
+	Matched  []string        `json:"matched"`
+	Policy   Policy          `json:"-"`
+	Trace    *Trace          `json:"trace,omitempty"`
+	Shadow   *ShadowDecision `json:"shadow,omitempty"`
+
+	Obligations []Obligation `json:"obligations,omitempty"`
+	Advice      []Obligation `json:"advice,omitempty"`
+	Unfulfilled []Obligation `json:"unfulfilled,omitempty"`
+
+	DanglingRoles []DanglingRole `json:"dangling_roles,omitempty"`
+	Violation     *SoDViolation  `json:"violation,omitempty"`
+
+	// volatile is set when the decision depends on the clock or on request metadata outside of the cache key,
+	// making it unsuitable for caching.
+	volatile bool
+}
+
+// ShadowDecision describes the effect a Decision would have had if its matched shadow policies were enforced.
+// Matched holds the IDs of the matched shadow policies and Diverges is true when Effect differs from the
+// enforced effect.
+type ShadowDecision struct {
+	Effect   PolicyEffect `json:"effect"`
+	Matched  []string     `json:"matched"`
+	Diverges bool         `json:"diverges"`
+}
+
+// Allowed returns true when the Decision permits the request.
+func (d *Decision) Allowed() bool {
+	return d.Effect == PolicyEffectAllow
+}
+
+// Err returns nil for allowed decisions or an Error describing the denial.
+func (d *Decision) Err() error {
+	if d.Allowed() {
+		return nil
+	}
+
+	if d.Violation != nil {
+		return NewErrSoDViolation(d.Violation)
+	}
+
+	if len(d.Unfulfilled) > 0 {
+		return NewErrObligationsUnfulfilled(d.Unfulfilled)
+	}
+
+	if d.Explicit && d.Policy != nil {
+		return NewErrRequestDeniedExplicit(d.Policy)
+	}
+
+	return NewErrRequestDeniedImplicit(errors.New("access denied because no policy allowed access"))
+}
@@ -1,84 +1,669 @@
 package redtape
 
 import (
+	"context"
 	"errors"
+	"runtime"
+	"sync"
+	"time"
 )
 
 // Enforcer interface provides methods to enforce policies against a request.
+// Decide returns the Decision reached for a request and reserves the error for processing failures.
+// Enforce returns a nil error when the request is allowed. EnforceBatch returns a Decision for each
+// request in order, loading policies with the provided context.
 type Enforcer interface {
 	Enforce(*Request) error
+	Decide(*Request) (*Decision, error)
+	EnforceBatch(context.Context, []*Request) ([]*Decision, error)
 }
 
 type enforcer struct {
 	manager PolicyManager
 	matcher Matcher
 	auditor Auditor
+	options EnforcerOptions
+	cache   *decisionCache
+	roles   *roleResolver
 }
 
 // NewEnforcer returns a default Enforcer combining a PolicyManager, Matcher, and Auditor.
-func NewEnforcer(manager PolicyManager, matcher Matcher, auditor Auditor) (Enforcer, error) {
-	return &enforcer{
+// Additional behavior can be configured with EnforcerOptions.
+func NewEnforcer(manager PolicyManager, matcher Matcher, auditor Auditor, opts ...EnforcerOption) (Enforcer, error) {
+	eopts := append([]EnforcerOption{SetMatcher(matcher), SetAuditor(auditor)}, opts...)
+
+	return NewEnforcerWithOptions(manager, eopts...)
+}
+
+// NewEnforcerWithOptions returns a default Enforcer for a PolicyManager configured entirely through
+// functional options. Settings that are not provided fall back to DefaultMatcher and DefaultPolicyEffect
+// as they are at the time of construction.
+func NewEnforcerWithOptions(manager PolicyManager, opts ...EnforcerOption) (Enforcer, error) {
+	if manager == nil {
+		return nil, errors.New("enforcer requires a policy manager")
+	}
+
+	o := NewEnforcerOptions(opts...)
+
+	if o.Matcher == nil {
+		return nil, errors.New("enforcer requires a matcher")
+	}
+
+	if o.Clock == nil {
+		o.Clock = time.Now
+	}
+
+	e := &enforcer{
 		manager: manager,
-		matcher: matcher,
-		auditor: auditor,
-	}, nil
+		matcher: o.Matcher,
+		auditor: o.Auditor,
+		options: o,
+	}
+
+	if o.Cache != nil {
+		n, ok := manager.(PolicyNotifier)
+		if !ok {
+			return nil, errors.New("decision cache requires a policy manager implementing PolicyNotifier")
+		}
+
+		e.cache = newDecisionCache(*o.Cache)
+		n.Subscribe(e.cache.invalidate)
+	}
+
+	if o.Roles != nil {
+		e.roles = newRoleResolver(o.Roles, o.RoleCacheTTL)
+
+		n, ok := o.Roles.(RoleNotifier)
+		if !ok && e.cache != nil {
+			return nil, errors.New("decision cache requires a role manager implementing RoleNotifier")
+		}
+
+		if ok {
+			n.SubscribeRoles(e.roles.invalidate)
+
+			if e.cache != nil {
+				n.SubscribeRoles(e.cache.invalidateRoles)
+			}
+		}
+	}
+
+	return e, nil
 }
 
-func NewDefaultEnforcer(manager PolicyManager) (Enforcer, error) {
-	return NewEnforcer(manager, DefaultMatcher, NewConsoleAuditor(AuditAll))
+// NewDefaultEnforcer returns an Enforcer using the DefaultMatcher and a console Auditor.
+func NewDefaultEnforcer(manager PolicyManager, opts ...EnforcerOption) (Enforcer, error) {
+	return NewEnforcer(manager, DefaultMatcher, NewConsoleAuditor(AuditAll), opts...)
 }
 
-// Enforce fulfills the Enforce method of Enforcer. The default implementation matches the Request against
-// the range of stored Policies and evaluating each.
-// Polices are matched first by Action, then Role, Resource, Scope and finally Condition. If a match is found, the
-// configured Policy Effect is applied.
-// TODO: return explicit PolicyEffect and use error to indicate processing failures
+// Enforce fulfills the Enforce method of Enforcer. It is a compatibility wrapper around Decide, returning nil
+// when the request is allowed and an error for denials or processing failures.
 func (e *enforcer) Enforce(r *Request) error {
-	allow := false
-	matched := []Policy{}
+	d, err := e.Decide(r)
+	if err != nil {
+		return err
+	}
 
+	return d.Err()
+}
+
+// Decide fulfills the Decide method of Enforcer. The default implementation matches the Request against
+// the range of stored Policies and evaluating each.
+// Candidate policies are evaluated in the order of SortPolicies, regardless of the order returned by the
+// PolicyManager. Policies outside their validity window at the time of the configured Clock are skipped.
+// Polices are matched first by Action, then Role or Subject, Resource, Scope and finally Condition. The
+// request Role and the effective roles of the request Subject are matched against the policy Roles, and the
+// Subject ID against the policy Subjects. When a RoleBindingManager is configured, the roles bound to the
+// request subject are matched as well. When a RoleManager is configured, policy roles are resolved by ID to
+// their current definition and references missing from the manager are reported in Decision#DanglingRoles.
+// Requests activating roles that violate a configured separation of duty constraint are denied without
+// evaluating policies. Template variables
+// in the policy Actions, Resources and Scopes are substituted from the request before matching.
+// The matched policies are resolved to a Decision by the configured CombiningAlgorithm, falling back to the
+// configured DefaultEffect. Matched shadow policies never affect the Decision; their would-be effect is reported in
+// Decision#Shadow and to Auditors implementing ShadowAuditor. Obligations of the Decision are fulfilled by
+// the registered ObligationHandlers and the Decision fails closed to deny when any remains unfulfilled.
+// Evaluation is aborted with the context error once the request context is done.
+// When the decision cache is enabled, cached decisions are returned without consulting the PolicyManager.
+// Decisions depending on policies with validity windows are not cached.
+func (e *enforcer) Decide(r *Request) (*Decision, error) {
 	e.auditReq(r)
 
-	pol, err := e.manager.FindByRequest(r)
+	if err := r.ctx().Err(); err != nil {
+		return nil, err
+	}
+
+	roles, err := e.requestRoles(r)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	d, err := e.checkSoD(r, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	if d != nil {
+		return e.finish(r, d), nil
+	}
+
+	if d, ok := e.fromCache(r, roles); ok {
+		return e.finish(r, d), nil
+	}
+
+	gen := e.cacheGeneration()
+
+	pol, err := findByRequest(e.manager, r)
+	if err != nil {
+		return nil, err
+	}
+
+	pol, err = e.resolveRoles(r.ctx(), pol)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err = e.decide(r, roles, ordered(pol))
+	if err != nil {
+		return nil, err
+	}
+
+	e.toCache(r, roles, gen, d)
+
+	return e.finish(r, d), nil
+}
+
+// EnforceBatch fulfills the EnforceBatch method of Enforcer. The candidate policy set is loaded once from
+// the PolicyManager using ctx and the requests are evaluated concurrently against it. Decisions are returned
+// in the order of the provided requests. If any request fails to evaluate or ctx or its own context is
+// done, the first error is returned.
+func (e *enforcer) EnforceBatch(ctx context.Context, reqs []*Request) ([]*Decision, error) {
+	if len(reqs) == 0 {
+		return []*Decision{}, nil
+	}
+
+	gen := e.cacheGeneration()
+
+	pol, err := allPolicies(ctx, e.manager)
+	if err != nil {
+		return nil, err
+	}
+
+	pol, err = e.resolveRoles(ctx, pol)
+	if err != nil {
+		return nil, err
+	}
+
+	pol = ordered(pol)
+
+	decisions := make([]*Decision, len(reqs))
+	errs := make([]error, len(reqs))
+
+	workers := e.options.BatchConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers > len(reqs) {
+		workers = len(reqs)
+	}
+
+	idx := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range idx {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+
+				e.auditReq(reqs[i])
+
+				roles, err := e.requestRoles(reqs[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				d, err := e.checkSoD(reqs[i], roles)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				if d != nil {
+					decisions[i] = e.finish(reqs[i], d)
+					continue
+				}
+
+				if d, ok := e.fromCache(reqs[i], roles); ok {
+					decisions[i] = e.finish(reqs[i], d)
+					continue
+				}
+
+				d, err = e.decide(reqs[i], roles, pol)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+
+				e.toCache(reqs[i], roles, gen, d)
+				decisions[i] = e.finish(reqs[i], d)
+			}
+		}()
+	}
+
+	for i := range reqs {
+		idx <- i
+	}
+
+	close(idx)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decisions, nil
+}
+
+// fromCache returns a cached decision for r holding roles.
+func (e *enforcer) fromCache(r *Request, roles []string) (*Decision, bool) {
+	if e.cache == nil {
+		return nil, false
+	}
+
+	return e.cache.get(e.cache.key(r, roles))
+}
+
+func (e *enforcer) cacheGeneration() uint64 {
+	if e.cache == nil {
+		return 0
+	}
+
+	return e.cache.generation()
+}
+
+// cacheCovers evaluates true when every input of p is part of the cache key: the RequestMetadata keys
+// referenced by its templates and the values of its conditions. Subject attributes are never part of the
+// cache key, and conditions not implementing ValueCondition may read any input, so policies using them are
+// never covered.
+func (e *enforcer) cacheCovers(p Policy) bool {
+	if e.cache == nil {
+		return true
+	}
+
+	keys, attrs := templateMetaKeys(p.Resources(), p.Actions(), p.Scopes())
+	if attrs {
+		return false
+	}
+
+	for name, c := range p.Conditions() {
+		vc, ok := c.(ValueCondition)
+		if !ok || !vc.ValueOnly() {
+			return false
+		}
+
+		keys = append(keys, name)
+	}
+
+	for _, k := range keys {
+		if !e.cache.hasKey(k) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *enforcer) toCache(r *Request, roles []string, gen uint64, d *Decision) {
+	if e.cache != nil && !d.volatile {
+		e.cache.put(e.cache.key(r, roles), gen, d)
+	}
+}
+
+// checkSoD returns a deny Decision when r violates a configured separation of duty constraint. Static
+// constraints are checked against roles, every role held by r, and dynamic constraints against the roles r
+// asserts.
+func (e *enforcer) checkSoD(r *Request, roles []string) (*Decision, error) {
+	v := e.options.Constraints.Check(r.SubjectID(), roles, SoDStatic)
+	if v == nil {
+		asserted, err := r.Roles()
+		if err != nil {
+			return nil, err
+		}
+
+		v = e.options.Constraints.Check(r.SubjectID(), asserted, SoDDynamic)
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	return &Decision{
+		Effect:    PolicyEffectDeny,
+		Matched:   []string{},
+		Violation: v,
+	}, nil
+}
+
+// resolveRoles returns pol with the policy roles resolved through the configured RoleManager.
+func (e *enforcer) resolveRoles(ctx context.Context, pol []Policy) ([]Policy, error) {
+	if e.roles == nil {
+		return pol, nil
+	}
+
+	return e.roles.resolvePolicies(ctx, pol)
+}
+
+// requestRoles returns the roles held by r, extended with the roles bound to its subject by the configured
+// RoleBindingManager. Bound roles, and transitively their sub roles, are expanded through the configured
+// RoleManager when they are found.
+func (e *enforcer) requestRoles(r *Request) ([]string, error) {
+	roles, err := r.Roles()
+	if err != nil || e.options.RoleBindings == nil || r.SubjectID() == "" {
+		return roles, err
+	}
+
+	ctx := r.ctx()
+
+	bindings, err := rolesFor(ctx, e.options.RoleBindings, r.SubjectID(), e.options.Clock())
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	res := []string{}
+
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				res = append(res, id)
+			}
+		}
+	}
+
+	add(roles...)
+
+	expanded := make(map[string]expandedRole)
+
+	for _, b := range bindings {
+		if e.roles == nil {
+			add(b.RoleID)
+			continue
+		}
+
+		role, found, err := e.roles.expand(ctx, NewRole(b.RoleID), expanded)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			add(b.RoleID)
+			continue
+		}
+
+		er, err := role.EffectiveRoles()
+		if err != nil {
+			return nil, err
+		}
+
+		add(roleIDs(er)...)
+	}
+
+	if len(res) == 0 {
+		return roles, nil
 	}
 
+	return res, nil
+}
+
+func (e *enforcer) decide(r *Request, roles []string, pol []Policy) (*Decision, error) {
+	matched := []Policy{}
+	shadow := []Policy{}
+
+	var trace *Trace
+	if e.options.Explain {
+		trace = &Trace{}
+	}
+
+	ctx := r.ctx()
+	volatile := false
+	dangling := []DanglingRole{}
+
 	for _, p := range pol {
-		match, err := e.evalPolicy(r, p)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		dangling = append(dangling, danglingRoles(p)...)
+
+		if p.Validity().Bounded() || !e.cacheCovers(p) {
+			volatile = true
+		}
+
+		var pt *PolicyTrace
+		if trace != nil {
+			pt = newPolicyTrace(p)
+		}
+
+		match, err := e.evalPolicy(r, roles, p, pt)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if pt != nil {
+			pt.Matched = match
+			trace.Policies = append(trace.Policies, *pt)
 		}
 
 		if !match {
 			continue
 		}
 
+		if p.Mode() == PolicyModeShadow {
+			shadow = append(shadow, p)
+			continue
+		}
+
 		matched = append(matched, p)
+	}
 
-		// deny overrides all
-		if p.Effect() == PolicyEffectDeny {
-			e.auditEffect(r, PolicyEffectDeny)
-			return NewErrRequestDeniedExplicit(p)
+	d := e.options.CombiningAlgorithm(matched, e.options.DefaultEffect)
+	d.Trace = trace
+	d.volatile = volatile
+
+	if len(dangling) > 0 {
+		d.DanglingRoles = dangling
+	}
+
+	if len(shadow) > 0 {
+		all := append(append([]Policy{}, matched...), shadow...)
+		sd := e.options.CombiningAlgorithm(all, e.options.DefaultEffect)
+
+		d.Shadow = &ShadowDecision{
+			Effect:   sd.Effect,
+			Matched:  policyIDs(shadow),
+			Diverges: sd.Effect != d.Effect,
 		}
+	}
+
+	d.Obligations, d.Advice = collectObligations(matched, d.Effect)
+
+	return d, nil
+}
+
+// finish fulfills the obligations of d and audits the final effect. Obligations are fulfilled for every
+// request, including decisions served from the cache.
+func (e *enforcer) finish(r *Request, d *Decision) *Decision {
+	fulfill(e.options.ObligationHandlers, r, d)
+	e.auditDecision(r, d)
+
+	return d
+}
+
+// EnforcerOptions configures the behavior of the default Enforcer.
+type EnforcerOptions struct {
+	Matcher            Matcher
+	Auditor            Auditor
+	DefaultEffect      PolicyEffect
+	CombiningAlgorithm CombiningAlgorithm
+	Explain            bool
+	BatchConcurrency   int
+	Cache              *CacheOptions
+	ObligationHandlers map[string]ObligationHandler
+	Clock              func() time.Time
+	RoleBindings       RoleBindingManager
+	Roles              RoleManager
+	RoleCacheTTL       time.Duration
+	Constraints        SoDConstraints
+}
+
+// EnforcerOption is a typed function allowing updates to EnforcerOptions through functional options.
+type EnforcerOption func(*EnforcerOptions)
+
+// NewEnforcerOptions returns EnforcerOptions configured with the provided functional options.
+// The Matcher and DefaultEffect default to the current DefaultMatcher and DefaultPolicyEffect, the
+// CombiningAlgorithm defaults to DenyOverrides, BatchConcurrency defaults to GOMAXPROCS, the Clock defaults to
+// time.Now and no Auditor is set.
+func NewEnforcerOptions(opts ...EnforcerOption) EnforcerOptions {
+	options := EnforcerOptions{
+		Matcher:            DefaultMatcher,
+		DefaultEffect:      DefaultPolicyEffect,
+		CombiningAlgorithm: DenyOverrides,
+		BatchConcurrency:   runtime.GOMAXPROCS(0),
+		Clock:              time.Now,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
+
+// SetMatcher sets the Matcher used to match policies to requests.
+func SetMatcher(m Matcher) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Matcher = m
+	}
+}
+
+// SetAuditor sets the Auditor receiving requests and effects. A nil Auditor disables auditing.
+func SetAuditor(a Auditor) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Auditor = a
+	}
+}
+
+// SetDefaultEffect sets the PolicyEffect applied when no matched policy decides a request.
+func SetDefaultEffect(effect PolicyEffect) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.DefaultEffect = effect
+	}
+}
+
+// SetCombiningAlgorithm sets the CombiningAlgorithm used to resolve matched policies to a Decision.
+func SetCombiningAlgorithm(a CombiningAlgorithm) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.CombiningAlgorithm = a
+	}
+}
 
-		allow = true
+// SetExplain enables explain mode. Decisions then carry a Trace recording the evaluation of every
+// candidate policy.
+func SetExplain(explain bool) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Explain = explain
 	}
+}
+
+// SetBatchConcurrency sets the maximum number of requests evaluated concurrently by EnforceBatch.
+func SetBatchConcurrency(n int) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.BatchConcurrency = n
+	}
+}
+
+// SetClock sets the function returning the current time used to check policy validity windows.
+func SetClock(now func() time.Time) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Clock = now
+	}
+}
+
+// SetRoleBindingManager sets the RoleBindingManager resolving the request subject into the roles bound to it.
+func SetRoleBindingManager(bm RoleBindingManager) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.RoleBindings = bm
+	}
+}
 
-	if !allow && DefaultPolicyEffect == PolicyEffectDeny {
-		e.auditEffect(r, PolicyEffectDeny)
-		return NewErrRequestDeniedImplicit(errors.New("access denied because no policy allowed access"))
+// SetRoleManager sets the RoleManager resolving policy roles and roles bound to the request subject into
+// their current definition.
+func SetRoleManager(rm RoleManager) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Roles = rm
 	}
+}
+
+// SetRoleCache caches roles resolved through the RoleManager for ttl. Changes to the RoleManager take up to
+// ttl to be reflected in decisions unless it implements RoleNotifier, which clears the cache on every change.
+func SetRoleCache(ttl time.Duration) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.RoleCacheTTL = ttl
+	}
+}
+
+// SetSoDConstraints sets the separation of duty constraints checked against each request at evaluation time.
+// Static constraints apply to every role the request holds, including roles bound to its subject, and dynamic
+// constraints to the roles the request asserts through its Role and Subject.
+func SetSoDConstraints(cs ...SoDConstraint) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Constraints = cs
+	}
+}
+
+// WithObligationHandler registers the ObligationHandler fulfilling obligations of type t. Decisions carrying
+// an obligation without a handler are denied.
+func WithObligationHandler(t string, h ObligationHandler) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		if o.ObligationHandlers == nil {
+			o.ObligationHandlers = make(map[string]ObligationHandler)
+		}
 
-	e.auditEffect(r, PolicyEffectAllow)
+		o.ObligationHandlers[t] = h
+	}
+}
 
-	return nil
+// SetDecisionCache enables caching of decisions in front of the PolicyManager. The manager must implement
+// PolicyNotifier so the cache is invalidated whenever a policy is created, updated or deleted. Likewise, a
+// RoleManager set with SetRoleManager must implement RoleNotifier so role changes invalidate the cache.
+func SetDecisionCache(c CacheOptions) EnforcerOption {
+	return func(o *EnforcerOptions) {
+		o.Cache = &c
+	}
 }
 
-func (e *enforcer) checkConditions(p Policy, r *Request) bool {
-	for key, cond := range p.Conditions() {
-		meta := RequestMetadataFromContext(r.Context)
-		if pass := cond.Meets(meta[key], r); !pass {
+func (e *enforcer) checkConditions(p Policy, r *Request, pt *PolicyTrace) bool {
+	conds := p.Conditions()
+	meta := RequestMetadataFromContext(r.Context)
+
+	for _, key := range conds.Names() {
+		cond := conds[key]
+		pass := cond.Meets(meta[key], r)
+		pt.record(TraceStageCondition, key, meta[key], []string{cond.Name()}, pass)
+
+		if !pass {
 			return false
 		}
 	}
@@ -86,29 +671,35 @@ func (e *enforcer) checkConditions(p Policy, r *Request) bool {
 	return true
 }
 
-func (e *enforcer) evalPolicy(r *Request, p Policy) (bool, error) {
+func (e *enforcer) evalPolicy(r *Request, roles []string, p Policy, pt *PolicyTrace) (bool, error) {
+	// check validity window
+	if v := p.Validity(); v.Bounded() {
+		now := e.options.Clock()
+		active := v.Active(now)
+
+		pt.record(TraceStageValidity, "", now, v.strings(), active)
+		if !active {
+			return false, nil
+		}
+	}
+
 	// match actions
-	am, err := e.matcher.MatchPolicy(p, p.Actions(), r.Action)
+	actions := expandTemplates(p.Actions(), r)
+
+	am, err := e.matcher.MatchPolicy(p, actions, r.Action)
 	if err != nil {
 		return false, err
 	}
 
+	pt.record(TraceStageAction, "", r.Action, actions, am)
 	if !am {
 		return false, nil
 	}
 
-	rm := false
-	// match roles
-	for _, role := range p.Roles() {
-		b, err := e.matcher.MatchRole(role, r.Role)
-		if err != nil {
-			return false, err
-		}
-
-		if b {
-			rm = true
-			break
-		}
+	// match roles and subjects
+	rm, err := matchPrincipal(e.matcher, p, r, roles, pt)
+	if err != nil {
+		return false, err
 	}
 
 	if !rm {
@@ -116,39 +707,114 @@ func (e *enforcer) evalPolicy(r *Request, p Policy) (bool, error) {
 	}
 
 	// match resources
-	resm, err := e.matcher.MatchPolicy(p, p.Resources(), r.Resource)
+	resources := expandTemplates(p.Resources(), r)
+
+	resm, err := e.matcher.MatchPolicy(p, resources, r.Resource)
 	if err != nil {
 		return false, err
 	}
+
+	pt.record(TraceStageResource, "", r.Resource, resources, resm)
 	if !resm {
 		return false, nil
 	}
 
 	// match scopes
-	scm, err := e.matcher.MatchPolicy(p, p.Scopes(), r.Scope)
+	scopes := expandTemplates(p.Scopes(), r)
+
+	scm, err := e.matcher.MatchPolicy(p, scopes, r.Scope)
 	if err != nil {
 		return false, err
 	}
+
+	pt.record(TraceStageScope, "", r.Scope, scopes, scm)
 	if !scm {
 		return false, nil
 	}
 
 	// check all conditions
-	if !e.checkConditions(p, r) {
+	if !e.checkConditions(p, r, pt) {
 		return false, nil
 	}
 
 	return true, nil
 }
 
+// ordered returns a copy of pol in evaluation order.
+func ordered(pol []Policy) []Policy {
+	ps := append([]Policy{}, pol...)
+	SortPolicies(ps)
+
+	return ps
+}
+
+// matchPrincipal evaluates true when m matches one of roles against the policy roles or the request subject
+// against the policy subjects. Each check is recorded to pt.
+func matchPrincipal(m Matcher, p Policy, r *Request, roles []string, pt *PolicyTrace) (bool, error) {
+	rm, err := matchRoles(m, p, roles)
+	if err != nil {
+		return false, err
+	}
+
+	var val interface{} = roles
+	if len(roles) == 1 && roles[0] == r.Role {
+		val = r.Role
+	}
+
+	pt.record(TraceStageRole, "", val, roleIDs(p.Roles()), rm)
+	if rm || len(p.Subjects()) == 0 {
+		return rm, nil
+	}
+
+	sm := false
+	if id := r.SubjectID(); id != "" {
+		sm, err = m.MatchPolicy(p, p.Subjects(), id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	pt.record(TraceStageSubject, "", r.SubjectID(), p.Subjects(), sm)
+
+	return sm, nil
+}
+
+// matchRoles evaluates true when m matches any of roles against at least one of the policy roles.
+func matchRoles(m Matcher, p Policy, roles []string) (bool, error) {
+	for _, role := range roles {
+		for _, pr := range p.Roles() {
+			b, err := m.MatchRole(pr, role)
+			if err != nil {
+				return false, err
+			}
+
+			if b {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
 func (e *enforcer) auditReq(req *Request) {
 	if e.auditor != nil {
 		e.auditor.LogRequest(req)
 	}
 }
 
-func (e *enforcer) auditEffect(req *Request, effect PolicyEffect) {
-	if e.auditor != nil {
-		e.auditor.LogPolicyEffect(req, effect)
+func (e *enforcer) auditDecision(req *Request, d *Decision) {
+	if e.auditor == nil {
+		return
+	}
+
+	if d.Violation != nil {
+		auditSoD(e.auditor, req, d.Violation)
+	}
+
+	e.auditor.LogPolicyEffect(req, d.Effect)
+
+	if sa, ok := e.auditor.(ShadowAuditor); ok && d.Shadow != nil {
+		sa.LogShadowEffect(req, d.Effect, d.Shadow)
 	}
 }
@@ -41,6 +41,11 @@ func (e *Error) Reason() string {
 	return e.reason
 }
 
+// Unwrap returns the underlying error, eg a *SoDViolation.
+func (e *Error) Unwrap() error {
+	return e.error
+}
+
 // NewErrRequestDeniedExplicit returns an error with for explicit denials.
 func NewErrRequestDeniedExplicit(p Policy) error {
 	return errors.WithStack(&Error{
@@ -66,3 +71,29 @@ func NewErrRequestDeniedImplicit(err error) error {
 		reason: "request denied because no matching policy was found",
 	})
 }
+
+// NewErrObligationsUnfulfilled returns an error for requests denied because obligations could not be fulfilled.
+func NewErrObligationsUnfulfilled(obs []Obligation) error {
+	types := make([]string, 0, len(obs))
+	for _, o := range obs {
+		types = append(types, o.Type)
+	}
+
+	return errors.WithStack(&Error{
+		error:  errors.Errorf("access denied because obligations %v could not be fulfilled", types),
+		code:   http.StatusForbidden,
+		status: http.StatusText(http.StatusForbidden),
+		reason: "request denied because a policy obligation could not be fulfilled",
+	})
+}
+
+// NewErrSoDViolation returns an error for requests denied because they activate roles violating a separation
+// of duty constraint.
+func NewErrSoDViolation(v *SoDViolation) error {
+	return errors.WithStack(&Error{
+		error:  v,
+		code:   http.StatusForbidden,
+		status: http.StatusText(http.StatusForbidden),
+		reason: "request denied because it activates roles violating a separation of duty constraint",
+	})
+}
@@ -0,0 +1,572 @@
+package expr
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// evalFunc evaluates a compiled expression tree node.
+type evalFunc func(act Activation) (interface{}, error)
+
+// typedExpr is a type checked and compiled expression tree node.
+type typedExpr struct {
+	typ  Type
+	pos  Pos
+	eval evalFunc
+}
+
+// value evaluates e, failing when the result is not of type want. Dyn accepts any supported value.
+func (e *typedExpr) value(act Activation, want Type) (interface{}, error) {
+	v, err := e.eval(act)
+	if err != nil {
+		return nil, err
+	}
+
+	if got := typeOf(v); got == Dyn {
+		return nil, errorf(e.pos, "unsupported value of type %T", v)
+	} else if want != Dyn && got != want {
+		return nil, errorf(e.pos, "expected %s, found %s", want, got)
+	}
+
+	return v, nil
+}
+
+func (e *typedExpr) bool(act Activation) (bool, error) {
+	v, err := e.value(act, Bool)
+	if err != nil {
+		return false, err
+	}
+
+	return v.(bool), nil
+}
+
+func (e *typedExpr) number(act Activation) (float64, error) {
+	v, err := e.value(act, Number)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.(float64), nil
+}
+
+type checker struct {
+	vars     map[string]Type
+	prefixes map[string]Type
+}
+
+func (c *checker) check(n node) (*typedExpr, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		v := n.value
+		return &typedExpr{typ: typeOf(v), pos: n.pos, eval: func(Activation) (interface{}, error) { return v, nil }}, nil
+	case *identNode:
+		return c.checkIdent(n)
+	case *listNode:
+		return c.checkList(n)
+	case *unaryNode:
+		return c.checkUnary(n)
+	case *binaryNode:
+		return c.checkBinary(n)
+	case *callNode:
+		return c.checkCall(n)
+	default:
+		return nil, errorf(n.position(), "unsupported expression")
+	}
+}
+
+// lookup returns the declared type of the variable name, preferring exact declarations over the longest
+// matching prefix.
+func (c *checker) lookup(name string) (Type, bool) {
+	if t, ok := c.vars[name]; ok {
+		return t, true
+	}
+
+	best, typ := "", Dyn
+	for p, t := range c.prefixes {
+		if len(name) > len(p) && strings.HasPrefix(name, p) && len(p) > len(best) {
+			best, typ = p, t
+		}
+	}
+
+	return typ, best != ""
+}
+
+func (c *checker) checkIdent(n *identNode) (*typedExpr, error) {
+	typ, ok := c.lookup(n.name)
+	if !ok {
+		return nil, errorf(n.pos, "undeclared variable %s", n.name)
+	}
+
+	name := n.name
+	e := &typedExpr{typ: typ, pos: n.pos}
+	e.eval = func(act Activation) (interface{}, error) {
+		v, ok := act(name)
+		if !ok {
+			return nil, errorf(n.pos, "variable %s is not set", name)
+		}
+
+		v = normalize(v)
+		if got := typeOf(v); got == Dyn {
+			return nil, errorf(n.pos, "variable %s has unsupported type %T", name, v)
+		} else if typ != Dyn && got != typ {
+			return nil, errorf(n.pos, "variable %s must be %s, found %s", name, typ, got)
+		}
+
+		return v, nil
+	}
+
+	return e, nil
+}
+
+func (c *checker) checkList(n *listNode) (*typedExpr, error) {
+	items := make([]*typedExpr, len(n.items))
+	for i, item := range n.items {
+		e, err := c.check(item)
+		if err != nil {
+			return nil, err
+		}
+
+		items[i] = e
+	}
+
+	return &typedExpr{typ: List, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := item.eval(act)
+			if err != nil {
+				return nil, err
+			}
+
+			l[i] = v
+		}
+
+		return l, nil
+	}}, nil
+}
+
+// expect fails when e cannot have the type want. Dyn accepts any type.
+func expect(e *typedExpr, want Type, op string) error {
+	if want != Dyn && e.typ != Dyn && e.typ != want {
+		return errorf(e.pos, "%s requires %s, found %s", op, want, e.typ)
+	}
+
+	return nil
+}
+
+func (c *checker) checkUnary(n *unaryNode) (*typedExpr, error) {
+	x, err := c.check(n.x)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == tokNot {
+		if err := expect(x, Bool, "!"); err != nil {
+			return nil, err
+		}
+
+		return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+			b, err := x.bool(act)
+			if err != nil {
+				return nil, err
+			}
+
+			return !b, nil
+		}}, nil
+	}
+
+	if err := expect(x, Number, "-"); err != nil {
+		return nil, err
+	}
+
+	return &typedExpr{typ: Number, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		f, err := x.number(act)
+		if err != nil {
+			return nil, err
+		}
+
+		return -f, nil
+	}}, nil
+}
+
+func (c *checker) checkBinary(n *binaryNode) (*typedExpr, error) {
+	x, err := c.check(n.x)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := c.check(n.y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case tokAnd, tokOr:
+		return checkLogical(n, x, y)
+	case tokEq, tokNe:
+		return checkEquality(n, x, y)
+	case tokLt, tokLe, tokGt, tokGe:
+		return checkOrdering(n, x, y)
+	case tokIn:
+		return checkIn(n, x, y)
+	case tokPlus:
+		return checkPlus(n, x, y)
+	default:
+		return checkArithmetic(n, x, y)
+	}
+}
+
+func checkLogical(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	op := n.op.String()
+	if err := expect(x, Bool, op); err != nil {
+		return nil, err
+	}
+
+	if err := expect(y, Bool, op); err != nil {
+		return nil, err
+	}
+
+	// && short circuits on false and || on true.
+	short := n.op == tokOr
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l, err := x.bool(act)
+		if err != nil {
+			return nil, err
+		}
+
+		if l == short {
+			return l, nil
+		}
+
+		return y.bool(act)
+	}}, nil
+}
+
+func checkEquality(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	if x.typ != Dyn && y.typ != Dyn && x.typ != y.typ {
+		return nil, errorf(n.pos, "cannot compare %s and %s with %s", x.typ, y.typ, n.op)
+	}
+
+	negate := n.op == tokNe
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l, err := x.value(act, Dyn)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := y.value(act, Dyn)
+		if err != nil {
+			return nil, err
+		}
+
+		return equal(l, r) != negate, nil
+	}}, nil
+}
+
+func ordered(t Type) bool {
+	return t == Number || t == String || t == Dyn
+}
+
+func checkOrdering(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	if !ordered(x.typ) || !ordered(y.typ) || (x.typ != Dyn && y.typ != Dyn && x.typ != y.typ) {
+		return nil, errorf(n.pos, "cannot compare %s and %s with %s", x.typ, y.typ, n.op)
+	}
+
+	op := n.op
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l, err := x.value(act, Dyn)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := y.value(act, Dyn)
+		if err != nil {
+			return nil, err
+		}
+
+		cmp, ok := compare(l, r)
+		if !ok {
+			return nil, errorf(n.pos, "cannot compare %s and %s with %s", typeOf(l), typeOf(r), op)
+		}
+
+		switch op {
+		case tokLt:
+			return cmp < 0, nil
+		case tokLe:
+			return cmp <= 0, nil
+		case tokGt:
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}}, nil
+}
+
+func checkIn(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	if err := expect(y, List, "in"); err != nil {
+		return nil, err
+	}
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		v, err := x.value(act, Dyn)
+		if err != nil {
+			return nil, err
+		}
+
+		l, err := y.value(act, List)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range l.([]interface{}) {
+			if equal(v, normalize(item)) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}}, nil
+}
+
+// checkPlus checks + adding numbers or concatenating strings.
+func checkPlus(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	typ := x.typ
+	if typ == Dyn {
+		typ = y.typ
+	}
+
+	if (typ != Number && typ != String && typ != Dyn) || (y.typ != Dyn && y.typ != typ) {
+		return nil, errorf(n.pos, "cannot add %s and %s", x.typ, y.typ)
+	}
+
+	return &typedExpr{typ: typ, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l, err := x.value(act, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := y.value(act, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		switch lv := l.(type) {
+		case float64:
+			if rv, ok := r.(float64); ok {
+				return lv + rv, nil
+			}
+		case string:
+			if rv, ok := r.(string); ok {
+				return lv + rv, nil
+			}
+		}
+
+		return nil, errorf(n.pos, "cannot add %s and %s", typeOf(l), typeOf(r))
+	}}, nil
+}
+
+func checkArithmetic(n *binaryNode, x, y *typedExpr) (*typedExpr, error) {
+	op := n.op.String()
+	if err := expect(x, Number, op); err != nil {
+		return nil, err
+	}
+
+	if err := expect(y, Number, op); err != nil {
+		return nil, err
+	}
+
+	kind := n.op
+
+	return &typedExpr{typ: Number, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		l, err := x.number(act)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := y.number(act)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case tokMinus:
+			return l - r, nil
+		case tokStar:
+			return l * r, nil
+		}
+
+		if r == 0 {
+			return nil, errorf(n.pos, "division by zero")
+		}
+
+		if kind == tokSlash {
+			return l / r, nil
+		}
+
+		return math.Mod(l, r), nil
+	}}, nil
+}
+
+// function is a builtin function with typed parameters.
+type function struct {
+	params []Type
+	result Type
+	call   func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"startsWith": {
+		params: []Type{String, String},
+		result: Bool,
+		call: func(args []interface{}) (interface{}, error) {
+			return strings.HasPrefix(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"endsWith": {
+		params: []Type{String, String},
+		result: Bool,
+		call: func(args []interface{}) (interface{}, error) {
+			return strings.HasSuffix(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"contains": {
+		params: []Type{String, String},
+		result: Bool,
+		call: func(args []interface{}) (interface{}, error) {
+			return strings.Contains(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"size": {
+		params: []Type{Dyn},
+		result: Number,
+		call: func(args []interface{}) (interface{}, error) {
+			switch v := args[0].(type) {
+			case string:
+				return float64(utf8.RuneCountInString(v)), nil
+			case []interface{}:
+				return float64(len(v)), nil
+			default:
+				return nil, &Error{Msg: "size requires string or list, found " + typeOf(v).String()}
+			}
+		},
+	},
+}
+
+func (c *checker) checkCall(n *callNode) (*typedExpr, error) {
+	switch n.fn {
+	case "has":
+		return c.checkHas(n)
+	case "matches":
+		return c.checkMatches(n)
+	}
+
+	fn, ok := functions[n.fn]
+	if !ok {
+		return nil, errorf(n.pos, "unknown function %s", n.fn)
+	}
+
+	if len(n.args) != len(fn.params) {
+		return nil, errorf(n.pos, "%s expects %d arguments, found %d", n.fn, len(fn.params), len(n.args))
+	}
+
+	args := make([]*typedExpr, len(n.args))
+	for i, arg := range n.args {
+		e, err := c.check(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := expect(e, fn.params[i], n.fn); err != nil {
+			return nil, err
+		}
+
+		args[i] = e
+	}
+
+	return &typedExpr{typ: fn.result, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		vals := make([]interface{}, len(args))
+		for i, arg := range args {
+			v, err := arg.value(act, fn.params[i])
+			if err != nil {
+				return nil, err
+			}
+
+			vals[i] = v
+		}
+
+		v, err := fn.call(vals)
+		if e, ok := err.(*Error); ok {
+			return nil, errorf(n.pos, "%s", e.Msg)
+		}
+
+		return v, err
+	}}, nil
+}
+
+// checkHas checks has(variable), testing whether a variable is set.
+func (c *checker) checkHas(n *callNode) (*typedExpr, error) {
+	if len(n.args) != 1 {
+		return nil, errorf(n.pos, "has expects 1 argument, found %d", len(n.args))
+	}
+
+	id, ok := n.args[0].(*identNode)
+	if !ok {
+		return nil, errorf(n.args[0].position(), "has requires a variable")
+	}
+
+	if _, ok := c.lookup(id.name); !ok {
+		return nil, errorf(id.pos, "undeclared variable %s", id.name)
+	}
+
+	name := id.name
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		_, ok := act(name)
+		return ok, nil
+	}}, nil
+}
+
+// checkMatches checks matches(s, pattern), compiling the literal regular expression pattern once.
+func (c *checker) checkMatches(n *callNode) (*typedExpr, error) {
+	if len(n.args) != 2 {
+		return nil, errorf(n.pos, "matches expects 2 arguments, found %d", len(n.args))
+	}
+
+	var pattern string
+
+	lit, ok := n.args[1].(*literalNode)
+	if ok {
+		pattern, ok = lit.value.(string)
+	}
+
+	if !ok {
+		return nil, errorf(n.args[1].position(), "matches requires a string literal pattern")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errorf(lit.pos, "invalid pattern: %v", err)
+	}
+
+	s, err := c.check(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := expect(s, String, "matches"); err != nil {
+		return nil, err
+	}
+
+	return &typedExpr{typ: Bool, pos: n.pos, eval: func(act Activation) (interface{}, error) {
+		v, err := s.value(act, String)
+		if err != nil {
+			return nil, err
+		}
+
+		return re.MatchString(v.(string)), nil
+	}}, nil
+}
@@ -0,0 +1,136 @@
+// Package expr implements a small, sandboxed boolean expression language. Expressions are compiled and type
+// checked once against a set of declared variables and evaluated against an Activation supplying their values.
+//
+// The language supports bool, number, string and list values, the operators ! && || == != < <= > >= in + - * / %,
+// list literals, parentheses and the functions startsWith, endsWith, contains, matches, size and has.
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pos is the position of a token in the source of an expression.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+// Error is returned when an expression fails to compile or evaluate.
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("expr: %d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+func errorf(pos Pos, format string, args ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Type is the static type of a value.
+type Type int
+
+const (
+	// Dyn values have a type only known at evaluation time.
+	Dyn Type = iota
+	// Bool values are booleans.
+	Bool
+	// Number values are float64.
+	Number
+	// String values are strings.
+	String
+	// List values are []interface{} of any type.
+	List
+)
+
+var typeNames = map[Type]string{
+	Dyn:    "dyn",
+	Bool:   "bool",
+	Number: "number",
+	String: "string",
+	List:   "list",
+}
+
+func (t Type) String() string {
+	return typeNames[t]
+}
+
+// Var declares a variable available to an expression. A Name ending in ".*" declares every dotted name below
+// its prefix as a Dyn variable, such as meta.* declaring meta.amount.
+type Var struct {
+	Name string
+	Type Type
+}
+
+// Activation returns the value of the variable name and whether it is set.
+type Activation func(name string) (interface{}, bool)
+
+// MapActivation returns an Activation looking up variables in m.
+func MapActivation(m map[string]interface{}) Activation {
+	return func(name string) (interface{}, bool) {
+		v, ok := m[name]
+		return v, ok
+	}
+}
+
+// Program is a compiled expression.
+type Program struct {
+	src  string
+	eval func(act Activation) (bool, error)
+}
+
+// Compile parses and type checks src against vars. The expression must evaluate to a bool.
+func Compile(src string, vars ...Var) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errorf(Pos{Line: 1, Column: 1}, "empty expression")
+	}
+
+	n, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{vars: make(map[string]Type), prefixes: make(map[string]Type)}
+	for _, v := range vars {
+		if strings.HasSuffix(v.Name, ".*") {
+			c.prefixes[strings.TrimSuffix(v.Name, "*")] = v.Type
+		} else {
+			c.vars[v.Name] = v.Type
+		}
+	}
+
+	e, err := c.check(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.typ != Bool && e.typ != Dyn {
+		return nil, errorf(n.position(), "expression must evaluate to bool, found %s", e.typ)
+	}
+
+	return &Program{src: src, eval: e.bool}, nil
+}
+
+// MustCompile is like Compile but panics on errors.
+func MustCompile(src string, vars ...Var) *Program {
+	p, err := Compile(src, vars...)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Source returns the source of the expression.
+func (p *Program) Source() string {
+	return p.src
+}
+
+// Eval evaluates the expression with variables supplied by act. An error is returned when a referenced
+// variable is not set or a value does not have the type required by an operation.
+func (p *Program) Eval(act Activation) (bool, error) {
+	return p.eval(act)
+}
@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testVars = []Var{
+	{Name: "request.scope", Type: String},
+	{Name: "request.roles", Type: List},
+	{Name: "meta.*", Type: Dyn},
+}
+
+func TestCompileEval(t *testing.T) {
+	act := MapActivation(map[string]interface{}{
+		"request.scope":  "eu",
+		"request.roles":  []string{"reader", "auditor"},
+		"meta.amount":    500,
+		"meta.owner":     "alice@example.com",
+		"meta.approved":  true,
+		"meta.tags":      []interface{}{"a", "b"},
+		"meta.nested.id": int64(7),
+	})
+
+	tests := []struct {
+		name    string
+		src     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "request example", src: `meta.amount < 1000 && request.scope == "eu"`, want: true},
+		{name: "false comparison", src: `meta.amount >= 1000`, want: false},
+		{name: "or short circuit", src: `request.scope == "eu" || meta.missing > 1`, want: true},
+		{name: "and short circuit", src: `request.scope == "us" && meta.missing > 1`, want: false},
+		{name: "not", src: `!meta.approved`, want: false},
+		{name: "in list", src: `"auditor" in request.roles`, want: true},
+		{name: "in literal list", src: `request.scope in ['eu', 'uk']`, want: true},
+		{name: "arithmetic", src: `meta.amount * 2 + 1 == 1001 && meta.amount % 7 == 3`, want: true},
+		{name: "negation", src: `-meta.amount < 0`, want: true},
+		{name: "concatenation", src: `request.scope + "-west" == 'eu-west'`, want: true},
+		{name: "string ordering", src: `request.scope < "fr"`, want: true},
+		{name: "functions", src: `endsWith(meta.owner, "@example.com") && startsWith(meta.owner, "al") && contains(meta.owner, "@")`, want: true},
+		{name: "size", src: `size(meta.tags) == 2 && size(request.scope) == 2`, want: true},
+		{name: "matches", src: `matches(meta.owner, "^[a-z]+@")`, want: true},
+		{name: "has", src: `has(meta.amount) && !has(meta.missing)`, want: true},
+		{name: "nested dyn", src: `meta.nested.id == 7`, want: true},
+		{name: "precedence", src: `true || false && false`, want: true},
+		{name: "grouping", src: `(true || false) && false`, want: false},
+		{name: "dyn type mismatch is unequal", src: `meta.amount == "500"`, want: false},
+		{name: "unset variable", src: `meta.missing > 1`, wantErr: true},
+		{name: "division by zero", src: `meta.amount / 0 > 1`, wantErr: true},
+		{name: "dyn ordering mismatch", src: `meta.owner < 5`, wantErr: true},
+		{name: "dyn result not bool", src: `meta.amount`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.src, testVars...)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := p.Eval(act)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Eval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantPos Pos
+		wantMsg string
+	}{
+		{name: "empty", src: " ", wantPos: Pos{1, 1}, wantMsg: "empty expression"},
+		{name: "undeclared", src: `user.id == "a"`, wantPos: Pos{1, 1}, wantMsg: "undeclared variable user.id"},
+		{name: "type mismatch", src: `request.scope == 1`, wantPos: Pos{1, 15}, wantMsg: "cannot compare string and number with =="},
+		{name: "not bool", src: `request.scope`, wantPos: Pos{1, 1}, wantMsg: "expression must evaluate to bool, found string"},
+		{name: "logical operand", src: `request.scope && true`, wantPos: Pos{1, 1}, wantMsg: "&& requires bool, found string"},
+		{name: "in string", src: `"e" in request.scope`, wantPos: Pos{1, 8}, wantMsg: "in requires list, found string"},
+		{name: "chained comparison", src: `1 < meta.a < 3`, wantPos: Pos{1, 12}, wantMsg: "comparisons cannot be chained"},
+		{name: "unknown function", src: `lower(request.scope) == "eu"`, wantPos: Pos{1, 1}, wantMsg: "unknown function lower"},
+		{name: "arity", src: `startsWith(request.scope)`, wantPos: Pos{1, 1}, wantMsg: "startsWith expects 2 arguments, found 1"},
+		{name: "dynamic pattern", src: `matches(request.scope, meta.p)`, wantPos: Pos{1, 24}, wantMsg: "matches requires a string literal pattern"},
+		{name: "invalid pattern", src: `matches(request.scope, "(")`, wantPos: Pos{1, 24}, wantMsg: "invalid pattern"},
+		{name: "unterminated string", src: "request.scope == \"eu", wantPos: Pos{1, 18}, wantMsg: "unterminated string"},
+		{name: "unexpected character", src: "\nrequest.scope == #", wantPos: Pos{2, 18}, wantMsg: "unexpected character '#'"},
+		{name: "missing paren", src: `(true`, wantPos: Pos{1, 6}, wantMsg: "expected ), found end of expression"},
+		{name: "trailing token", src: `true true`, wantPos: Pos{1, 6}, wantMsg: `unexpected "true"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src, testVars...)
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Compile() error = %v, want *Error", err)
+			}
+
+			if e.Pos != tt.wantPos {
+				t.Errorf("Compile() error position = %v, want %v", e.Pos, tt.wantPos)
+			}
+
+			if !strings.Contains(e.Msg, tt.wantMsg) {
+				t.Errorf("Compile() error = %q, want %q", e.Msg, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokTrue
+	tokFalse
+	tokIn
+	tokAnd
+	tokOr
+	tokNot
+	tokEq
+	tokNe
+	tokLt
+	tokLe
+	tokGt
+	tokGe
+	tokPlus
+	tokMinus
+	tokStar
+	tokSlash
+	tokPercent
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+	tokDot
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of expression",
+	tokIdent:    "identifier",
+	tokNumber:   "number",
+	tokString:   "string",
+	tokTrue:     "true",
+	tokFalse:    "false",
+	tokIn:       "in",
+	tokAnd:      "&&",
+	tokOr:       "||",
+	tokNot:      "!",
+	tokEq:       "==",
+	tokNe:       "!=",
+	tokLt:       "<",
+	tokLe:       "<=",
+	tokGt:       ">",
+	tokGe:       ">=",
+	tokPlus:     "+",
+	tokMinus:    "-",
+	tokStar:     "*",
+	tokSlash:    "/",
+	tokPercent:  "%",
+	tokLParen:   "(",
+	tokRParen:   ")",
+	tokLBracket: "[",
+	tokRBracket: "]",
+	tokComma:    ",",
+	tokDot:      ".",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+type token struct {
+	kind tokenKind
+	pos  Pos
+	text string
+	num  float64
+}
+
+var keywords = map[string]tokenKind{
+	"true":  tokTrue,
+	"false": tokFalse,
+	"in":    tokIn,
+}
+
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"&&", tokAnd},
+	{"||", tokOr},
+	{"==", tokEq},
+	{"!=", tokNe},
+	{"<=", tokLe},
+	{">=", tokGe},
+	{"!", tokNot},
+	{"<", tokLt},
+	{">", tokGt},
+	{"+", tokPlus},
+	{"-", tokMinus},
+	{"*", tokStar},
+	{"/", tokSlash},
+	{"%", tokPercent},
+	{"(", tokLParen},
+	{")", tokRParen},
+	{"[", tokLBracket},
+	{"]", tokRBracket},
+	{",", tokComma},
+	{".", tokDot},
+}
+
+// lex splits src into tokens, ending with a tokEOF token.
+func lex(src string) ([]token, error) {
+	var toks []token
+
+	line, col := 1, 1
+	rs := []rune(src)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		pos := Pos{Line: line, Column: col}
+
+		advance := func(n int) {
+			for j := 0; j < n; j++ {
+				if rs[i] == '\n' {
+					line++
+					col = 1
+				} else {
+					col++
+				}
+				i++
+			}
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(1)
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(rs) && (rs[j] == '_' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+
+			text := string(rs[i:j])
+			kind, ok := keywords[text]
+			if !ok {
+				kind = tokIdent
+			}
+
+			toks = append(toks, token{kind: kind, pos: pos, text: text})
+			advance(j - i)
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(rs) && unicode.IsDigit(rs[j]) {
+				j++
+			}
+
+			if j+1 < len(rs) && rs[j] == '.' && unicode.IsDigit(rs[j+1]) {
+				j++
+				for j < len(rs) && unicode.IsDigit(rs[j]) {
+					j++
+				}
+			}
+
+			text := string(rs[i:j])
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errorf(pos, "invalid number %s", text)
+			}
+
+			toks = append(toks, token{kind: tokNumber, pos: pos, text: text, num: n})
+			advance(j - i)
+		case r == '"' || r == '\'':
+			s, n, err := lexString(rs[i:], pos)
+			if err != nil {
+				return nil, err
+			}
+
+			toks = append(toks, token{kind: tokString, pos: pos, text: s})
+			advance(n)
+		default:
+			rest := string(rs[i:])
+			matched := false
+
+			for _, op := range operators {
+				if strings.HasPrefix(rest, op.text) {
+					toks = append(toks, token{kind: op.kind, pos: pos, text: op.text})
+					advance(len(op.text))
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				return nil, errorf(pos, "unexpected character %q", r)
+			}
+		}
+	}
+
+	toks = append(toks, token{kind: tokEOF, pos: Pos{Line: line, Column: col}})
+
+	return toks, nil
+}
+
+// lexString reads the quoted string at the start of rs, returning its unescaped value and the number of runes
+// consumed.
+func lexString(rs []rune, pos Pos) (string, int, error) {
+	quote := rs[0]
+
+	var sb strings.Builder
+
+	for i := 1; i < len(rs); i++ {
+		switch rs[i] {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\n':
+			return "", 0, errorf(pos, "unterminated string")
+		case '\\':
+			i++
+			if i == len(rs) {
+				return "", 0, errorf(pos, "unterminated string")
+			}
+
+			switch rs[i] {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '\\', '"', '\'':
+				sb.WriteRune(rs[i])
+			default:
+				return "", 0, errorf(pos, "invalid escape sequence \\%c", rs[i])
+			}
+		default:
+			sb.WriteRune(rs[i])
+		}
+	}
+
+	return "", 0, errorf(pos, "unterminated string")
+}
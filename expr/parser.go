@@ -0,0 +1,291 @@
+package expr
+
+import "strings"
+
+// node is an element of the parsed expression tree.
+type node interface {
+	position() Pos
+}
+
+type literalNode struct {
+	pos   Pos
+	value interface{}
+}
+
+type identNode struct {
+	pos  Pos
+	name string
+}
+
+type listNode struct {
+	pos   Pos
+	items []node
+}
+
+type unaryNode struct {
+	pos Pos
+	op  tokenKind
+	x   node
+}
+
+type binaryNode struct {
+	pos  Pos
+	op   tokenKind
+	x, y node
+}
+
+type callNode struct {
+	pos  Pos
+	fn   string
+	args []node
+}
+
+func (n *literalNode) position() Pos { return n.pos }
+func (n *identNode) position() Pos   { return n.pos }
+func (n *listNode) position() Pos    { return n.pos }
+func (n *unaryNode) position() Pos   { return n.pos }
+func (n *binaryNode) position() Pos  { return n.pos }
+func (n *callNode) position() Pos    { return n.pos }
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+// parse returns the expression tree of src.
+func parse(src string) (node, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.pos, "unexpected %s", describe(tok))
+	}
+
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.toks[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, errorf(tok.pos, "expected %s, found %s", kind, describe(tok))
+	}
+
+	return tok, nil
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokIdent, tokNumber:
+		return tok.kind.String() + " " + tok.text
+	case tokString:
+		return "string " + quote(tok.text)
+	case tokEOF:
+		return tok.kind.String()
+	default:
+		return quote(tok.text)
+	}
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// parseBinary parses a left associative chain of operators from ops with operands parsed by operand.
+func (p *parser) parseBinary(operand func() (node, error), ops ...tokenKind) (node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if !hasKind(ops, tok.kind) {
+			return x, nil
+		}
+
+		p.next()
+
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		x = &binaryNode{pos: tok.pos, op: tok.kind, x: x, y: y}
+	}
+}
+
+func hasKind(kinds []tokenKind, kind tokenKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(p.parseAnd, tokOr)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseRelation, tokAnd)
+}
+
+// parseRelation parses comparisons, which do not chain.
+func (p *parser) parseRelation() (node, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if !hasKind([]tokenKind{tokEq, tokNe, tokLt, tokLe, tokGt, tokGe, tokIn}, tok.kind) {
+		return x, nil
+	}
+
+	p.next()
+
+	y, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); hasKind([]tokenKind{tokEq, tokNe, tokLt, tokLe, tokGt, tokGe, tokIn}, next.kind) {
+		return nil, errorf(next.pos, "comparisons cannot be chained, use && to combine them")
+	}
+
+	return &binaryNode{pos: tok.pos, op: tok.kind, x: x, y: y}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary(p.parseMultiplicative, tokPlus, tokMinus)
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseBinary(p.parseUnary, tokStar, tokSlash, tokPercent)
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	if tok.kind != tokNot && tok.kind != tokMinus {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return &unaryNode{pos: tok.pos, op: tok.kind, x: x}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{pos: tok.pos, value: tok.num}, nil
+	case tokString:
+		return &literalNode{pos: tok.pos, value: tok.text}, nil
+	case tokTrue, tokFalse:
+		return &literalNode{pos: tok.pos, value: tok.kind == tokTrue}, nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokRParen); err != nil {
+			return nil, err
+		}
+
+		return x, nil
+	case tokLBracket:
+		items, err := p.parseList(tokRBracket)
+		if err != nil {
+			return nil, err
+		}
+
+		return &listNode{pos: tok.pos, items: items}, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			p.next()
+
+			args, err := p.parseList(tokRParen)
+			if err != nil {
+				return nil, err
+			}
+
+			return &callNode{pos: tok.pos, fn: tok.text, args: args}, nil
+		}
+
+		name := tok.text
+		for p.peek().kind == tokDot {
+			p.next()
+
+			part, err := p.expect(tokIdent)
+			if err != nil {
+				return nil, err
+			}
+
+			name += "." + part.text
+		}
+
+		return &identNode{pos: tok.pos, name: name}, nil
+	default:
+		return nil, errorf(tok.pos, "unexpected %s", describe(tok))
+	}
+}
+
+// parseList parses comma separated expressions up to the closing token end.
+func (p *parser) parseList(end tokenKind) ([]node, error) {
+	items := []node{}
+
+	if p.peek().kind == end {
+		p.next()
+		return items, nil
+	}
+
+	for {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, x)
+
+		tok := p.next()
+		switch tok.kind {
+		case end:
+			return items, nil
+		case tokComma:
+		default:
+			return nil, errorf(tok.pos, "expected , or %s, found %s", end, describe(tok))
+		}
+	}
+}
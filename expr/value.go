@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// normalize converts the Go value v to the representation used during evaluation: bool, float64, string or
+// []interface{}. Values of other types are returned unchanged and fail any operation using them.
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+
+		return x.String()
+	case []string:
+		l := make([]interface{}, len(x))
+		for i, s := range x {
+			l[i] = s
+		}
+
+		return l
+	case []float64:
+		l := make([]interface{}, len(x))
+		for i, f := range x {
+			l[i] = f
+		}
+
+		return l
+	case []int:
+		l := make([]interface{}, len(x))
+		for i, n := range x {
+			l[i] = float64(n)
+		}
+
+		return l
+	default:
+		return v
+	}
+}
+
+// typeOf returns the Type of a normalized value, or Dyn for unsupported values.
+func typeOf(v interface{}) Type {
+	switch v.(type) {
+	case bool:
+		return Bool
+	case float64:
+		return Number
+	case string:
+		return String
+	case []interface{}:
+		return List
+	default:
+		return Dyn
+	}
+}
+
+// equal reports whether the normalized values a and b are equal. Values of different types are never equal.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case float64:
+		y, ok := b.(float64)
+		return ok && x == y
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !equal(normalize(x[i]), normalize(y[i])) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// compare orders the normalized values a and b, which must both be numbers or both be strings.
+func compare(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(x, y), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,91 @@
+package redtape
+
+import "github.com/blushft/redtape/expr"
+
+// exprVars are the variables available to ExprCondition expressions.
+var exprVars = []expr.Var{
+	{Name: "request.resource", Type: expr.String},
+	{Name: "request.action", Type: expr.String},
+	{Name: "request.role", Type: expr.String},
+	{Name: "request.scope", Type: expr.String},
+	{Name: "request.roles", Type: expr.List},
+	{Name: "subject.id", Type: expr.String},
+	{Name: "subject.attr.*", Type: expr.Dyn},
+	{Name: "meta.*", Type: expr.Dyn},
+	{Name: "value", Type: expr.Dyn},
+}
+
+// ExprCondition evaluates a boolean expression over the request, its subject and metadata, such as
+// `meta.amount < 1000 && request.scope == "eu"`. The expression is compiled and type checked when the
+// condition is built. See package expr for the language.
+//
+// Expressions can reference request.resource, request.action, request.role, request.scope, request.roles
+// holding the effective roles of the request, subject.id, subject.attr.<path>, meta.<path> for request metadata
+// and value holding the condition value. Attribute and metadata paths can reach into nested values, see
+// Request#Lookup.
+type ExprCondition struct {
+	Expression string `json:"expression"`
+
+	program *expr.Program
+}
+
+// NewExprCondition returns an ExprCondition compiling src.
+func NewExprCondition(src string) (*ExprCondition, error) {
+	c := &ExprCondition{}
+	if err := c.compile(src); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *ExprCondition) compile(src string) error {
+	p, err := expr.Compile(src, exprVars...)
+	if err != nil {
+		return err
+	}
+
+	c.Expression = src
+	c.program = p
+
+	return nil
+}
+
+// Name fulfills the Name method of Condition.
+func (c *ExprCondition) Name() string {
+	return "expr"
+}
+
+// Meets evaluates true when the expression evaluates to true for the request. Evaluation errors, such as a
+// referenced metadata key not being set, evaluate false.
+func (c *ExprCondition) Meets(val interface{}, r *Request) bool {
+	if c.program == nil || r == nil {
+		return false
+	}
+
+	ok, err := c.program.Eval(exprActivation(val, r))
+
+	return err == nil && ok
+}
+
+// DecodeOptions fulfills the DecodeOptions method of ConditionCodec, compiling the "expression" option.
+func (c *ExprCondition) DecodeOptions(opts map[string]interface{}, _ ConditionRegistry) error {
+	src, _ := opts["expression"].(string)
+
+	return c.compile(src)
+}
+
+// EncodeOptions fulfills the EncodeOptions method of ConditionCodec.
+func (c *ExprCondition) EncodeOptions() map[string]interface{} {
+	return map[string]interface{}{"expression": c.Expression}
+}
+
+func exprActivation(val interface{}, r *Request) expr.Activation {
+	return func(name string) (interface{}, bool) {
+		if name == "value" {
+			return val, val != nil
+		}
+
+		return r.Lookup(name)
+	}
+}
@@ -0,0 +1,120 @@
+package redtape
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func jsonExprCond() []byte {
+	return []byte(`
+[
+	{
+		"name": "small_eu_payment",
+		"type": "expr",
+		"options": {
+			"expression": "meta.amount < 1000 && request.scope == \"eu\" && !(\"suspended\" in request.roles)"
+		}
+	}
+]
+`)
+}
+
+func TestExprCondition(t *testing.T) {
+	var opts []ConditionOptions
+	if err := json.Unmarshal(jsonExprCond(), &opts); err != nil {
+		t.Fatalf("failed to unmarshal options: %v", err)
+	}
+
+	conds, err := NewConditions(opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := NewSubject("alice", NewRole("payer"))
+
+	tests := []struct {
+		name string
+		req  *Request
+		want bool
+	}{
+		{"allowed", NewRequest("payments", "create", "payer", "eu", map[string]interface{}{"amount": 500}), true},
+		{"too_large", NewRequest("payments", "create", "payer", "eu", map[string]interface{}{"amount": 1500.5}), false},
+		{"wrong_scope", NewRequest("payments", "create", "payer", "us", map[string]interface{}{"amount": 500}), false},
+		{"missing_amount", NewRequest("payments", "create", "payer", "eu"), false},
+		{"suspended", NewRequest("payments", "create", "suspended", "eu", map[string]interface{}{"amount": 500}), false},
+		{"subject", NewSubjectRequest("payments", "create", sub, "eu", map[string]interface{}{"amount": json.Number("10")}), true},
+		{"nil_request", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conds["small_eu_payment"].Meets(nil, tt.req); got != tt.want {
+				t.Errorf("Condition.Meets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(conds.Options(), opts) {
+		t.Errorf("Conditions.Options() = %v, want %v", conds.Options(), opts)
+	}
+}
+
+func TestExprConditionVariables(t *testing.T) {
+	sub := NewSubject("alice", NewRole("payer"))
+	sub.Attributes["region"] = map[string]interface{}{"code": "eu-west"}
+
+	r := NewSubjectRequest("payments", "create", sub, "eu", map[string]interface{}{
+		"order": map[string]interface{}{"total": 20, "currency": "EUR"},
+	})
+
+	tests := []struct {
+		name string
+		src  string
+		val  interface{}
+		want bool
+	}{
+		{"request", `request.resource == "payments" && request.action == "create" && request.role == ""`, nil, true},
+		{"subject", `subject.id == "alice" && "payer" in request.roles`, nil, true},
+		{"attributes", `startsWith(subject.attr.region.code, "eu")`, nil, true},
+		{"nested_meta", `meta.order.total * 2 == 40 && meta.order.currency == "EUR"`, nil, true},
+		{"value", `value == "x"`, "x", true},
+		{"unset_value", `value == "x"`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewExprCondition(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.Meets(tt.val, r); got != tt.want {
+				t.Errorf("ExprCondition.Meets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprConditionCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"empty", ""},
+		{"syntax", "meta.amount <"},
+		{"undeclared", "user.name == 'a'"},
+		{"types", "request.scope > 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConditions([]ConditionOptions{
+				{Name: "c", Type: "expr", Options: map[string]interface{}{"expression": tt.src}},
+			}, nil)
+			if err == nil {
+				t.Errorf("NewConditions() error = nil, want compile error")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package redtape
+
+import "context"
+
+// Grant describes a resource, action and scope combination that a policy allows or denies. Conditions
+// holds the names of the conditions that must be met for the grant to apply.
+type Grant struct {
+	Resource   string       `json:"resource"`
+	Action     string       `json:"action"`
+	Scope      string       `json:"scope"`
+	Effect     PolicyEffect `json:"effect"`
+	PolicyID   string       `json:"policy_id"`
+	Conditions []string     `json:"conditions,omitempty"`
+}
+
+// Conditional returns true when the grant depends on conditions evaluated at request time.
+func (g Grant) Conditional() bool {
+	return len(g.Conditions) > 0
+}
+
+// RoleGrants returns the effective grants of a role across all policies stored in pm. The role is expanded
+// through Role#EffectiveRoles and a policy applies when m matches any of the expanded roles against one of
+// the policy roles. Allow grants fully covered by an unconditional deny grant are removed, while deny
+// grants are always returned so partial and conditional denials stay visible. Shadow policies and policies
+// outside their validity window at the time of the configured clock are ignored.
+func RoleGrants(pm PolicyManager, m Matcher, role *Role, opts ...EvalOption) ([]Grant, error) {
+	return RoleGrantsContext(context.Background(), pm, m, role, opts...)
+}
+
+// RoleGrantsContext is RoleGrants passing ctx to pm when it implements ContextPolicyManager.
+func RoleGrantsContext(ctx context.Context, pm PolicyManager, m Matcher, role *Role, opts ...EvalOption) ([]Grant, error) {
+	o := NewEvalOptions(opts...)
+
+	er, err := role.EffectiveRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	pols, err := allPolicies(ctx, pm)
+	if err != nil {
+		return nil, err
+	}
+
+	var allows, denies []Grant
+	denyPolicies := make(map[string]Policy)
+
+	now := o.Clock()
+
+	for _, p := range pols {
+		if p.Mode() == PolicyModeShadow || !p.Validity().Active(now) {
+			continue
+		}
+
+		applies, err := policyAppliesToRoles(m, p, er)
+		if err != nil {
+			return nil, err
+		}
+
+		if !applies {
+			continue
+		}
+
+		grants := policyGrants(p)
+		if p.Effect() == PolicyEffectDeny {
+			denies = append(denies, grants...)
+			denyPolicies[p.ID()] = p
+
+			continue
+		}
+
+		allows = append(allows, grants...)
+	}
+
+	result := make([]Grant, 0, len(allows)+len(denies))
+
+	for _, a := range allows {
+		covered, err := grantCovered(m, a, denies, denyPolicies)
+		if err != nil {
+			return nil, err
+		}
+
+		if !covered {
+			result = append(result, a)
+		}
+	}
+
+	return append(result, denies...), nil
+}
+
+func policyAppliesToRoles(m Matcher, p Policy, roles []*Role) (bool, error) {
+	for _, pr := range p.Roles() {
+		for _, r := range roles {
+			match, err := m.MatchRole(pr, r.ID)
+			if err != nil {
+				return false, err
+			}
+
+			if match {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func policyGrants(p Policy) []Grant {
+	conds := p.Conditions().Names()
+	var grants []Grant
+
+	for _, res := range orAny(p.Resources()) {
+		for _, act := range orAny(p.Actions()) {
+			for _, sc := range orAny(p.Scopes()) {
+				grants = append(grants, Grant{
+					Resource:   res,
+					Action:     act,
+					Scope:      sc,
+					Effect:     p.Effect(),
+					PolicyID:   p.ID(),
+					Conditions: conds,
+				})
+			}
+		}
+	}
+
+	return grants
+}
+
+// grantCovered returns true when an unconditional deny grant matches every element of g.
+func grantCovered(m Matcher, g Grant, denies []Grant, pols map[string]Policy) (bool, error) {
+	for _, d := range denies {
+		if d.Conditional() {
+			continue
+		}
+
+		p := pols[d.PolicyID]
+		covered := true
+
+		for _, pair := range [][2]string{{d.Resource, g.Resource}, {d.Action, g.Action}, {d.Scope, g.Scope}} {
+			match, err := m.MatchPolicy(p, []string{pair[0]}, pair[1])
+			if err != nil {
+				return false, err
+			}
+
+			if !match {
+				covered = false
+				break
+			}
+		}
+
+		if covered {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// orAny returns def or a wildcard matching any value when def is nil.
+func orAny(def []string) []string {
+	if def == nil {
+		return []string{"*"}
+	}
+
+	return def
+}
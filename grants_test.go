@@ -0,0 +1,89 @@
+package redtape
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleGrants(t *testing.T) {
+	pm := NewManager()
+	expiry := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	pols := []Policy{
+		MustNewPolicy(
+			PolicyID("edit_docs"),
+			SetResources("/docs/*"),
+			SetActions("read", "write"),
+			WithRole(NewRole("editor")),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("read_comments"),
+			SetResources("/comments"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			WithCondition(ConditionOptions{Name: "internal", Type: "bool", Options: map[string]interface{}{"value": true}}),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("no_writes"),
+			SetResources("*"),
+			SetActions("write"),
+			WithRole(NewRole("editor")),
+			PolicyDeny(),
+		),
+		MustNewPolicy(
+			PolicyID("old_exports"),
+			SetResources("/exports"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			SetNotAfter(expiry),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("admin_only"),
+			SetResources("*"),
+			SetActions("*"),
+			WithRole(NewRole("admin")),
+			PolicyAllow(),
+		),
+	}
+
+	for _, p := range pols {
+		require.NoError(t, pm.Create(p))
+	}
+
+	role := NewRole("editor", NewRole("viewer"))
+
+	grants, err := RoleGrants(pm, NewMatcher(), role)
+	require.NoError(t, err)
+	require.Len(t, grants, 3)
+
+	allowed := map[string]Grant{}
+	for _, g := range grants {
+		if g.Effect == PolicyEffectAllow {
+			allowed[g.PolicyID+":"+g.Action] = g
+		}
+	}
+
+	assert.Contains(t, allowed, "edit_docs:read")
+	assert.NotContains(t, allowed, "edit_docs:write", "write should be removed by deny")
+	require.Contains(t, allowed, "read_comments:read")
+	assert.True(t, allowed["read_comments:read"].Conditional())
+	assert.Equal(t, []string{"internal"}, allowed["read_comments:read"].Conditions)
+	assert.Equal(t, "*", allowed["read_comments:read"].Scope)
+
+	grants, err = RoleGrants(pm, NewMatcher(), role, SetEvalClock(func() time.Time { return expiry.Add(-time.Hour) }))
+	require.NoError(t, err)
+	require.Len(t, grants, 4)
+
+	ids := []string{}
+	for _, g := range grants {
+		ids = append(ids, g.PolicyID)
+	}
+
+	assert.Contains(t, ids, "old_exports")
+}
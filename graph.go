@@ -0,0 +1,235 @@
+package redtape
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrRoleCycle is returned when a role would inherit from itself through its sub roles.
+var ErrRoleCycle = errors.New("role cycle detected")
+
+// RoleGraph indexes roles by ID along with the sub role edges between them. Roles reachable through
+// several paths are only visited once and the graph never contains a cycle.
+type RoleGraph struct {
+	roles map[string]*Role
+	edges map[string][]string
+}
+
+// NewRoleGraph returns a RoleGraph holding roles and all of their sub roles.
+func NewRoleGraph(roles ...*Role) (*RoleGraph, error) {
+	g := &RoleGraph{
+		roles: make(map[string]*Role),
+		edges: make(map[string][]string),
+	}
+
+	for _, r := range roles {
+		if err := g.Add(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// Add adds r to the graph, replacing the sub roles previously recorded for r.ID. Sub roles embedded in r
+// are merged with the edges already known for them. The graph is left unchanged when the result would
+// contain a cycle.
+func (g *RoleGraph) Add(r *Role) error {
+	roles := make(map[string]*Role, len(g.roles))
+	for id, role := range g.roles {
+		roles[id] = role
+	}
+
+	edges := make(map[string][]string, len(g.edges))
+	for id, subs := range g.edges {
+		edges[id] = subs
+	}
+
+	delete(edges, r.ID)
+
+	if err := collectRole(r, roles, edges, make(map[*Role]bool), nil); err != nil {
+		return err
+	}
+
+	if err := checkCycles(edges); err != nil {
+		return err
+	}
+
+	g.roles, g.edges = roles, edges
+
+	return nil
+}
+
+// CheckRoleGraph returns an error wrapping ErrRoleCycle when r cannot be stored alongside roles without
+// forming a cycle. A role in roles with the ID of r is replaced by r.
+func CheckRoleGraph(r *Role, roles ...*Role) error {
+	sorted := append([]*Role{}, roles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	g, err := NewRoleGraph(sorted...)
+	if err != nil {
+		return err
+	}
+
+	return g.Add(r)
+}
+
+// collectRole records r and its sub roles in roles and edges. path holds the IDs of the roles embedding r
+// to stop on cycles formed by the role pointers themselves, visited the roles already collected.
+func collectRole(r *Role, roles map[string]*Role, edges map[string][]string, visited map[*Role]bool, path []string) error {
+	for i, id := range path {
+		if id == r.ID {
+			return cycleError(append(path[i:], r.ID))
+		}
+	}
+
+	if visited[r] {
+		return nil
+	}
+
+	visited[r] = true
+
+	if _, ok := roles[r.ID]; !ok || len(path) == 0 {
+		roles[r.ID] = r
+	}
+
+	path = append(path, r.ID)
+
+	for _, sr := range r.Roles {
+		edges[r.ID] = appendUnique(edges[r.ID], sr.ID)
+
+		if err := collectRole(sr, roles, edges, visited, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkCycles(edges map[string][]string) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(edges))
+
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			for i, pid := range path {
+				if pid == id {
+					return cycleError(append(path[i:], id))
+				}
+			}
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, sub := range edges[id] {
+			if err := visit(sub, path); err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+
+		return nil
+	}
+
+	ids := make([]string, 0, len(edges))
+	for id := range edges {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := visit(id, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cycleError(path []string) error {
+	return fmt.Errorf("%w: %s", ErrRoleCycle, strings.Join(path, " -> "))
+}
+
+func appendUnique(ids []string, id string) []string {
+	for _, i := range ids {
+		if i == id {
+			return ids
+		}
+	}
+
+	return append(append([]string{}, ids...), id)
+}
+
+// Role returns the role with id and whether it is part of the graph.
+func (g *RoleGraph) Role(id string) (*Role, bool) {
+	r, ok := g.roles[id]
+	return r, ok
+}
+
+// Descendants returns the IDs of every role inherited by id, directly or through other sub roles, in
+// depth first order.
+func (g *RoleGraph) Descendants(id string) []string {
+	return walkRoles(id, g.edges)
+}
+
+// Ancestors returns the IDs of every role inheriting id, directly or through other roles, in depth
+// first order.
+func (g *RoleGraph) Ancestors(id string) []string {
+	parents := make(map[string][]string)
+
+	ids := make([]string, 0, len(g.edges))
+	for pid := range g.edges {
+		ids = append(ids, pid)
+	}
+
+	sort.Strings(ids)
+
+	for _, pid := range ids {
+		for _, sub := range g.edges[pid] {
+			parents[sub] = append(parents[sub], pid)
+		}
+	}
+
+	return walkRoles(id, parents)
+}
+
+// EffectiveRoles returns id followed by its descendants.
+func (g *RoleGraph) EffectiveRoles(id string) []string {
+	return append([]string{id}, g.Descendants(id)...)
+}
+
+func walkRoles(id string, edges map[string][]string) []string {
+	var ids []string
+
+	seen := map[string]bool{id: true}
+
+	var walk func(string)
+	walk = func(id string) {
+		for _, next := range edges[id] {
+			if seen[next] {
+				continue
+			}
+
+			seen[next] = true
+			ids = append(ids, next)
+			walk(next)
+		}
+	}
+
+	walk(id)
+
+	return ids
+}
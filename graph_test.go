@@ -0,0 +1,190 @@
+package redtape
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRoleEffectiveRoles(t *testing.T) {
+	viewer := NewRole("viewer")
+	diamond := NewRole("admin", NewRole("editor", viewer), NewRole("reviewer", viewer))
+
+	deep := NewRole("level_0")
+	for i, r := 1, deep; i <= 20; i++ {
+		sub := NewRole(fmt.Sprintf("level_%d", i))
+		r.Roles = append(r.Roles, sub)
+		r = sub
+	}
+
+	wide := NewRole("wide")
+	for i := 0; i < 20; i++ {
+		wide.Roles = append(wide.Roles, NewRole(fmt.Sprintf("sub_%d", i)))
+	}
+
+	cyclic := NewRole("a", NewRole("b"))
+	cyclic.Roles[0].Roles = append(cyclic.Roles[0].Roles, cyclic)
+
+	tests := []struct {
+		name    string
+		role    *Role
+		want    int
+		wantErr bool
+	}{
+		{"single", NewRole("single"), 1, false},
+		{"diamond", diamond, 4, false},
+		{"deep", deep, 21, false},
+		{"wide", wide, 21, false},
+		{"cycle", cyclic, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			er, err := tt.role.EffectiveRoles()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EffectiveRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr && !errors.Is(err, ErrRoleCycle) {
+				t.Errorf("EffectiveRoles() error = %v, want ErrRoleCycle", err)
+			}
+
+			if len(er) != tt.want {
+				t.Errorf("EffectiveRoles() = %v, want %d roles", roleIDs(er), tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleAddRoleCycle(t *testing.T) {
+	viewer := NewRole("viewer")
+	editor := NewRole("editor", viewer)
+	admin := NewRole("admin", editor)
+
+	if err := viewer.AddRole(admin); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("AddRole() error = %v, want ErrRoleCycle", err)
+	}
+
+	if len(viewer.Roles) != 0 {
+		t.Errorf("AddRole() left %v in viewer", roleIDs(viewer.Roles))
+	}
+
+	if err := admin.AddRole(viewer); err != nil {
+		t.Errorf("AddRole() error = %v", err)
+	}
+}
+
+func TestRoleDeepDiamond(t *testing.T) {
+	const depth = 40
+
+	// every level embeds both roles of the next one, giving 2^depth paths to the bottom role
+	bottom := NewRole("bottom")
+	next := []*Role{bottom, bottom}
+	for i := depth; i > 0; i-- {
+		next = []*Role{
+			NewRole(fmt.Sprintf("left_%d", i), next...),
+			NewRole(fmt.Sprintf("right_%d", i), next...),
+		}
+	}
+
+	top := NewRole("top", next...)
+
+	er, err := top.EffectiveRoles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(er), 2*depth+2; got != want {
+		t.Errorf("EffectiveRoles() = %d roles, want %d", got, want)
+	}
+
+	g, err := NewRoleGraph(top)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(g.Descendants("top")), 2*depth+1; got != want {
+		t.Errorf("Descendants() = %d roles, want %d", got, want)
+	}
+
+	if got, want := len(g.Ancestors("bottom")), 2*depth+1; got != want {
+		t.Errorf("Ancestors() = %d roles, want %d", got, want)
+	}
+
+	if err := CheckRoleGraph(NewRole("bottom", top)); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("CheckRoleGraph() error = %v, want ErrRoleCycle", err)
+	}
+}
+
+func TestRoleGraph(t *testing.T) {
+	viewer := NewRole("viewer")
+	editor := NewRole("editor", viewer)
+	reviewer := NewRole("reviewer", viewer)
+	admin := NewRole("admin", editor, reviewer)
+
+	g, err := NewRoleGraph(admin, NewRole("auditor", viewer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"descendants", g.Descendants("admin"), []string{"editor", "viewer", "reviewer"}},
+		{"descendants_leaf", g.Descendants("viewer"), nil},
+		{"ancestors", g.Ancestors("viewer"), []string{"auditor", "editor", "admin", "reviewer"}},
+		{"ancestors_root", g.Ancestors("admin"), nil},
+		{"effective", g.EffectiveRoles("editor"), []string{"editor", "viewer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if err := g.Add(NewRole("viewer", NewRole("admin"))); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("Add() error = %v, want ErrRoleCycle", err)
+	}
+
+	if got := g.Descendants("viewer"); got != nil {
+		t.Errorf("Add() changed the graph on error, viewer descendants = %v", got)
+	}
+
+	if err := g.Add(NewRole("editor")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := g.Ancestors("viewer"), []string{"auditor", "reviewer", "admin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ancestors() after update = %v, want %v", got, want)
+	}
+}
+
+func TestRoleManagerCycle(t *testing.T) {
+	rm := NewRoleManager()
+
+	if err := rm.Create(NewRole("editor", NewRole("viewer"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rm.Create(NewRole("viewer", NewRole("editor"))); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("Create() error = %v, want ErrRoleCycle", err)
+	}
+
+	if err := rm.Create(NewRole("viewer")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rm.Update(NewRole("viewer", NewRole("guest", NewRole("editor")))); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("Update() error = %v, want ErrRoleCycle", err)
+	}
+
+	if err := rm.Update(NewRole("viewer", NewRole("guest"))); err != nil {
+		t.Errorf("Update() error = %v", err)
+	}
+}
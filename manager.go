@@ -1,9 +1,12 @@
 package redtape
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 )
 
 // PolicyManager contains methods to allow query, update, and removal of policies.
@@ -20,12 +23,179 @@ type PolicyManager interface {
 	FindByScope(string) ([]Policy, error)
 }
 
+// ContextPolicyManager is a PolicyManager providing context aware variants of its methods that honor
+// cancellation and deadlines.
+type ContextPolicyManager interface {
+	PolicyManager
+
+	CreateContext(context.Context, Policy) error
+	UpdateContext(context.Context, Policy) error
+	GetContext(context.Context, string) (Policy, error)
+	DeleteContext(context.Context, string) error
+	AllContext(ctx context.Context, limit, offset int) ([]Policy, error)
+
+	FindByRequestContext(context.Context, *Request) ([]Policy, error)
+	FindByRoleContext(context.Context, string) ([]Policy, error)
+	FindByResourceContext(context.Context, string) ([]Policy, error)
+	FindByScopeContext(context.Context, string) ([]Policy, error)
+}
+
+// ExpiredPolicyFinder is implemented by PolicyManagers able to list the policies whose validity window ended
+// before a given time, allowing them to be cleaned up.
+type ExpiredPolicyFinder interface {
+	FindExpired(at time.Time) ([]Policy, error)
+	FindExpiredContext(ctx context.Context, at time.Time) ([]Policy, error)
+}
+
+// PolicyChange identifies the kind of change reported by a PolicyNotifier.
+type PolicyChange string
+
+const (
+	// PolicyCreated is reported after a policy is created.
+	PolicyCreated PolicyChange = "create"
+	// PolicyUpdated is reported after a policy is updated.
+	PolicyUpdated PolicyChange = "update"
+	// PolicyDeleted is reported after a policy is deleted.
+	PolicyDeleted PolicyChange = "delete"
+)
+
+// PolicyListener is a typed function receiving the kind of change and the ID of the changed policy.
+type PolicyListener func(change PolicyChange, id string)
+
+// PolicyNotifier is implemented by PolicyManagers reporting changes of their policy set to listeners.
+type PolicyNotifier interface {
+	Subscribe(PolicyListener)
+}
+
+// PolicyListeners is a helper allowing PolicyManager implementations to fulfill PolicyNotifier.
+type PolicyListeners struct {
+	listeners []PolicyListener
+	mu        sync.RWMutex
+}
+
+// Subscribe adds a listener.
+func (l *PolicyListeners) Subscribe(fn PolicyListener) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.listeners = append(l.listeners, fn)
+}
+
+// Notify calls every subscribed listener with the change.
+func (l *PolicyListeners) Notify(change PolicyChange, id string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, fn := range l.listeners {
+		fn(change, id)
+	}
+}
+
+// RoleChange identifies the kind of change reported by a RoleNotifier.
+type RoleChange string
+
+const (
+	// RoleCreated is reported after a role is created.
+	RoleCreated RoleChange = "create"
+	// RoleUpdated is reported after a role is updated.
+	RoleUpdated RoleChange = "update"
+	// RoleDeleted is reported after a role is deleted.
+	RoleDeleted RoleChange = "delete"
+)
+
+// RoleListener is a typed function receiving the kind of change and the ID of the changed role.
+type RoleListener func(change RoleChange, id string)
+
+// RoleNotifier is implemented by RoleManagers reporting changes of their roles to listeners.
+type RoleNotifier interface {
+	SubscribeRoles(RoleListener)
+}
+
+// RoleListeners is a helper allowing RoleManager implementations to fulfill RoleNotifier.
+type RoleListeners struct {
+	listeners []RoleListener
+	mu        sync.RWMutex
+}
+
+// SubscribeRoles adds a listener.
+func (l *RoleListeners) SubscribeRoles(fn RoleListener) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.listeners = append(l.listeners, fn)
+}
+
+// NotifyRoles calls every subscribed listener with the change.
+func (l *RoleListeners) NotifyRoles(change RoleChange, id string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, fn := range l.listeners {
+		fn(change, id)
+	}
+}
+
+// expiredPolicies returns the policies of pols expired at the provided time.
+func expiredPolicies(pols []Policy, at time.Time) []Policy {
+	expired := []Policy{}
+	for _, p := range pols {
+		if p.Validity().Expired(at) {
+			expired = append(expired, p)
+		}
+	}
+
+	return expired
+}
+
+// policyPageSize is the number of policies requested per page when loading a full policy set.
+const policyPageSize = 100
+
+// allPolicies pages through PolicyManager#All to load every stored policy. The context is used when pm
+// implements ContextPolicyManager.
+func allPolicies(ctx context.Context, pm PolicyManager) ([]Policy, error) {
+	pols := []Policy{}
+
+	for offset := 0; ; offset += policyPageSize {
+		var page []Policy
+		var err error
+
+		if cm, ok := pm.(ContextPolicyManager); ok {
+			page, err = cm.AllContext(ctx, policyPageSize, offset)
+		} else {
+			page, err = pm.All(policyPageSize, offset)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		pols = append(pols, page...)
+
+		if len(page) < policyPageSize {
+			return pols, nil
+		}
+	}
+}
+
+// findByRequest returns the candidate policies for r, passing the request context when pm implements
+// ContextPolicyManager.
+func findByRequest(pm PolicyManager, r *Request) ([]Policy, error) {
+	if cm, ok := pm.(ContextPolicyManager); ok {
+		return cm.FindByRequestContext(r.ctx(), r)
+	}
+
+	return pm.FindByRequest(r)
+}
+
 type defaultManager struct {
+	PolicyListeners
+
 	policies map[string]Policy
 	mu       sync.RWMutex
 }
 
-// NewManager returns a default memory backed policy manager.
+// NewManager returns a default memory backed policy manager. Policies are returned in the order of
+// SortPolicies. The manager implements ContextPolicyManager, ExpiredPolicyFinder and PolicyNotifier.
 func NewManager() PolicyManager {
 	return &defaultManager{
 		policies: make(map[string]Policy),
@@ -34,30 +204,61 @@ func NewManager() PolicyManager {
 
 // Create adds a policy to the manager.
 func (m *defaultManager) Create(p Policy) error {
+	return m.CreateContext(context.Background(), p)
+}
+
+// CreateContext adds a policy to the manager unless ctx is done.
+func (m *defaultManager) CreateContext(ctx context.Context, p Policy) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	if _, exists := m.policies[p.ID()]; exists {
+		m.mu.Unlock()
 		return fmt.Errorf("policy %s already registered", p.ID())
 	}
 
 	m.policies[p.ID()] = p
+	m.mu.Unlock()
+
+	m.Notify(PolicyCreated, p.ID())
 
 	return nil
 }
 
 // Update replaces a named policy with the provided policy.
 func (m *defaultManager) Update(p Policy) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.UpdateContext(context.Background(), p)
+}
+
+// UpdateContext replaces a named policy with the provided policy unless ctx is done.
+func (m *defaultManager) UpdateContext(ctx context.Context, p Policy) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	m.mu.Lock()
 	m.policies[p.ID()] = p
+	m.mu.Unlock()
+
+	m.Notify(PolicyUpdated, p.ID())
 
 	return nil
 }
 
 // Get retrieves a policy by id or error if one does not exist.
 func (m *defaultManager) Get(id string) (Policy, error) {
+	return m.GetContext(context.Background(), id)
+}
+
+// GetContext retrieves a policy by id unless ctx is done.
+func (m *defaultManager) GetContext(ctx context.Context, id string) (Policy, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -71,70 +272,130 @@ func (m *defaultManager) Get(id string) (Policy, error) {
 
 // Delete removes a policy by id.
 func (m *defaultManager) Delete(id string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.DeleteContext(context.Background(), id)
+}
 
+// DeleteContext removes a policy by id unless ctx is done.
+func (m *defaultManager) DeleteContext(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
 	delete(m.policies, id)
+	m.mu.Unlock()
+
+	m.Notify(PolicyDeleted, id)
+
 	return nil
 }
 
 // All returns a slice containing all policies.
 func (m *defaultManager) All(limit int, offset int) ([]Policy, error) {
-	m.mu.RLock()
+	return m.AllContext(context.Background(), limit, offset)
+}
 
-	pkeys := make([]string, len(m.policies))
-	i := 0
-	for k := range m.policies {
-		pkeys[i] = k
-		i++
+// AllContext returns a slice containing all policies ordered by SortPolicies unless ctx is done.
+func (m *defaultManager) AllContext(ctx context.Context, limit int, offset int) ([]Policy, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	start, end := limitIndices(limit, offset, len(m.policies))
-	sort.Strings(pkeys)
+	m.mu.RLock()
 
-	pols := make([]Policy, 0, len(pkeys[start:end]))
-	for _, p := range pkeys[start:end] {
-		pols = append(pols, m.policies[p])
+	pols := make([]Policy, 0, len(m.policies))
+	for _, p := range m.policies {
+		pols = append(pols, p)
 	}
 
 	m.mu.RUnlock()
 
-	return pols, nil
+	SortPolicies(pols)
+	start, end := limitIndices(limit, offset, len(pols))
+
+	return pols[start:end], nil
 }
 
-func (m *defaultManager) findAll() ([]Policy, error) {
+func (m *defaultManager) findAll(ctx context.Context) ([]Policy, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
-	defer m.mu.RUnlock()
 
 	ps := make([]Policy, 0, len(m.policies))
 	for _, p := range m.policies {
 		ps = append(ps, p)
 	}
 
+	m.mu.RUnlock()
+
+	SortPolicies(ps)
+
 	return ps, nil
 }
 
+// FindExpired returns all policies expired at the provided time.
+func (m *defaultManager) FindExpired(at time.Time) ([]Policy, error) {
+	return m.FindExpiredContext(context.Background(), at)
+}
+
+// FindExpiredContext returns all policies expired at the provided time unless ctx is done.
+func (m *defaultManager) FindExpiredContext(ctx context.Context, at time.Time) ([]Policy, error) {
+	pols, err := m.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return expiredPolicies(pols, at), nil
+}
+
 // FindByRequest returns all policies matching a Request.
 func (m *defaultManager) FindByRequest(r *Request) ([]Policy, error) {
-	return m.findAll()
+	return m.findAll(context.Background())
+}
+
+// FindByRequestContext returns all policies matching a Request unless ctx is done.
+func (m *defaultManager) FindByRequestContext(ctx context.Context, _ *Request) ([]Policy, error) {
+	return m.findAll(ctx)
 }
 
 // FindByRole returns all policies matching a Role.
 func (m *defaultManager) FindByRole(_ string) ([]Policy, error) {
-	return m.findAll()
+	return m.findAll(context.Background())
+}
+
+// FindByRoleContext returns all policies matching a Role unless ctx is done.
+func (m *defaultManager) FindByRoleContext(ctx context.Context, _ string) ([]Policy, error) {
+	return m.findAll(ctx)
 }
 
 // FindByResource returns all policies matching a Resource.
 func (m *defaultManager) FindByResource(_ string) ([]Policy, error) {
-	return m.findAll()
+	return m.findAll(context.Background())
+}
+
+// FindByResourceContext returns all policies matching a Resource unless ctx is done.
+func (m *defaultManager) FindByResourceContext(ctx context.Context, _ string) ([]Policy, error) {
+	return m.findAll(ctx)
 }
 
 // FindByResource returns all policies matching a Resource.
 func (m *defaultManager) FindByScope(_ string) ([]Policy, error) {
-	return m.findAll()
+	return m.findAll(context.Background())
 }
 
-// RoleManager provides methods to store and retrieve role sets.
+// FindByScopeContext returns all policies matching a Scope unless ctx is done.
+func (m *defaultManager) FindByScopeContext(ctx context.Context, _ string) ([]Policy, error) {
+	return m.findAll(ctx)
+}
+
+// ErrRoleNotFound is returned by RoleManagers when a requested role does not exist.
+var ErrRoleNotFound = errors.New("role does not exist")
+
+// RoleManager provides methods to store and retrieve role sets. Get returns an error wrapping
+// ErrRoleNotFound when the role does not exist. GetMatching returns a page of the roles whose ID matches a
+// pattern following the semantics of a Matcher.
 type RoleManager interface {
 	Create(*Role) error
 	Update(*Role) error
@@ -143,55 +404,189 @@ type RoleManager interface {
 	Delete(string) error
 	All(limit, offset int) ([]*Role, error)
 
-	GetMatching(string) ([]*Role, error)
+	GetMatching(pattern string, limit, offset int) ([]*Role, error)
+}
+
+// ContextRoleManager is a RoleManager providing context aware variants of its methods that honor
+// cancellation and deadlines.
+type ContextRoleManager interface {
+	RoleManager
+
+	CreateContext(context.Context, *Role) error
+	UpdateContext(context.Context, *Role) error
+	GetContext(context.Context, string) (*Role, error)
+	GetByNameContext(context.Context, string) (*Role, error)
+	DeleteContext(context.Context, string) error
+	AllContext(ctx context.Context, limit, offset int) ([]*Role, error)
+
+	GetMatchingContext(ctx context.Context, pattern string, limit, offset int) ([]*Role, error)
+}
+
+// getRole returns the role with id from rm, passing ctx when rm implements ContextRoleManager.
+func getRole(ctx context.Context, rm RoleManager, id string) (*Role, error) {
+	if crm, ok := rm.(ContextRoleManager); ok {
+		return crm.GetContext(ctx, id)
+	}
+
+	return rm.Get(id)
+}
+
+// MatchRoles returns the page of roles, ordered by ID, whose ID matches pattern using m. A nil Matcher
+// falls back to the DefaultMatcher.
+func MatchRoles(m Matcher, roles []*Role, pattern string, limit, offset int) ([]*Role, error) {
+	if m == nil {
+		m = DefaultMatcher
+	}
+
+	sorted := append([]*Role{}, roles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	matched := []*Role{}
+	for _, r := range sorted {
+		ok, err := m.MatchPolicy(nil, []string{pattern}, r.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+
+	start, end := limitIndices(limit, offset, len(matched))
+
+	return matched[start:end], nil
 }
 
 type defaultRoleManager struct {
-	roles map[string]*Role
-	mu    sync.RWMutex
+	RoleListeners
+
+	roles   map[string]*Role
+	matcher Matcher
+	mu      sync.RWMutex
+}
+
+// RoleManagerOptions configures the default RoleManager.
+type RoleManagerOptions struct {
+	Matcher Matcher
+}
+
+// RoleManagerOption is a typed function allowing updates to RoleManagerOptions through functional options.
+type RoleManagerOption func(*RoleManagerOptions)
+
+// SetRoleMatcher sets the Matcher used by GetMatching. The DefaultMatcher is used when it is not set.
+func SetRoleMatcher(m Matcher) RoleManagerOption {
+	return func(o *RoleManagerOptions) {
+		o.Matcher = m
+	}
 }
 
-func NewRoleManager() RoleManager {
+// NewRoleManager returns a RoleManager storing roles in memory. The manager also implements RoleNotifier.
+func NewRoleManager(opts ...RoleManagerOption) RoleManager {
+	var o RoleManagerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return &defaultRoleManager{
-		roles: make(map[string]*Role),
+		roles:   make(map[string]*Role),
+		matcher: o.Matcher,
 	}
 }
 
 func (m *defaultRoleManager) Create(r *Role) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.CreateContext(context.Background(), r)
+}
 
-	if _, exists := m.roles[r.ID]; exists {
-		return fmt.Errorf("role %s already registered", r.ID)
+func (m *defaultRoleManager) CreateContext(ctx context.Context, r *Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	m.roles[r.ID] = r
+	if err := m.store(r, false); err != nil {
+		return err
+	}
+
+	m.NotifyRoles(RoleCreated, r.ID)
 
 	return nil
 }
 
 func (m *defaultRoleManager) Update(r *Role) error {
+	return m.UpdateContext(context.Background(), r)
+}
+
+func (m *defaultRoleManager) UpdateContext(ctx context.Context, r *Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := m.store(r, true); err != nil {
+		return err
+	}
+
+	m.NotifyRoles(RoleUpdated, r.ID)
+
+	return nil
+}
+
+// store stores r unless it would form a role cycle or, when overwrite is false, a role with its ID exists.
+func (m *defaultRoleManager) store(r *Role, overwrite bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if _, exists := m.roles[r.ID]; exists && !overwrite {
+		return fmt.Errorf("role %s already registered", r.ID)
+	}
+
+	if err := m.checkRole(r); err != nil {
+		return err
+	}
+
 	m.roles[r.ID] = r
 
 	return nil
 }
 
+// checkRole returns an error when storing r would form a role cycle with the registered roles.
+func (m *defaultRoleManager) checkRole(r *Role) error {
+	roles := make([]*Role, 0, len(m.roles))
+	for _, role := range m.roles {
+		roles = append(roles, role)
+	}
+
+	return CheckRoleGraph(r, roles...)
+}
+
 func (m *defaultRoleManager) Get(id string) (*Role, error) {
+	return m.GetContext(context.Background(), id)
+}
+
+func (m *defaultRoleManager) GetContext(ctx context.Context, id string) (*Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	r, ok := m.roles[id]
 	if !ok {
-		return nil, fmt.Errorf("role %s does not exist", id)
+		return nil, fmt.Errorf("%w: %s", ErrRoleNotFound, id)
 	}
 
 	return r, nil
 }
 
 func (m *defaultRoleManager) GetByName(name string) (*Role, error) {
+	return m.GetByNameContext(context.Background(), name)
+}
+
+func (m *defaultRoleManager) GetByNameContext(ctx context.Context, name string) (*Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -205,14 +600,32 @@ func (m *defaultRoleManager) GetByName(name string) (*Role, error) {
 }
 
 func (m *defaultRoleManager) Delete(id string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.DeleteContext(context.Background(), id)
+}
+
+func (m *defaultRoleManager) DeleteContext(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	m.mu.Lock()
 	delete(m.roles, id)
+	m.mu.Unlock()
+
+	m.NotifyRoles(RoleDeleted, id)
+
 	return nil
 }
 
 func (m *defaultRoleManager) All(limit, offset int) ([]*Role, error) {
+	return m.AllContext(context.Background(), limit, offset)
+}
+
+func (m *defaultRoleManager) AllContext(ctx context.Context, limit, offset int) ([]*Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	m.mu.RLock()
 
 	rkeys := make([]string, len(m.roles))
@@ -235,8 +648,24 @@ func (m *defaultRoleManager) All(limit, offset int) ([]*Role, error) {
 	return roles, nil
 }
 
-func (m *defaultRoleManager) GetMatching(id string) ([]*Role, error) {
-	panic("not implemented")
+func (m *defaultRoleManager) GetMatching(pattern string, limit, offset int) ([]*Role, error) {
+	return m.GetMatchingContext(context.Background(), pattern, limit, offset)
+}
+
+func (m *defaultRoleManager) GetMatchingContext(ctx context.Context, pattern string, limit, offset int) ([]*Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(m.roles))
+	for _, r := range m.roles {
+		roles = append(roles, r)
+	}
+
+	return MatchRoles(m.matcher, roles, pattern, limit, offset)
 }
 
 func limitIndices(limit, offset, length int) (int, int) {
@@ -1,22 +1,44 @@
 package manager
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/blushft/redtape"
 )
 
 type FileOptions struct {
-	Name string
-	Path string
+	Name     string
+	Path     string
+	Matcher  redtape.Matcher
+	Registry redtape.ConditionRegistry
 }
 
 type FileOption func(*FileOptions)
 
+// SetMatcher sets the Matcher used by the role manager to match role patterns. The DefaultMatcher is used
+// when it is not set.
+func SetMatcher(m redtape.Matcher) FileOption {
+	return func(o *FileOptions) {
+		o.Matcher = m
+	}
+}
+
+// SetConditionRegistry sets the ConditionRegistry used to build the conditions of stored policies. The default
+// ConditionRegistry is used when it is not set.
+func SetConditionRegistry(reg redtape.ConditionRegistry) FileOption {
+	return func(o *FileOptions) {
+		o.Registry = reg
+	}
+}
+
 func NewFileOptions(opts ...FileOption) FileOptions {
 	o := FileOptions{
 		Name: "redtape",
@@ -29,8 +51,14 @@ func NewFileOptions(opts ...FileOption) FileOptions {
 	return o
 }
 
+// File stores policies, roles and role bindings as JSON files in FileOptions#Path. Managers returned by the
+// same File are safe for concurrent use; Files sharing a path are not synchronized.
 type File struct {
 	options FileOptions
+
+	// mu guards reads and writes of the stored files, updateMu serializes their read-modify-write cycles.
+	mu       sync.RWMutex
+	updateMu sync.Mutex
 }
 
 func NewFile(opts ...FileOption) *File {
@@ -39,32 +67,74 @@ func NewFile(opts ...FileOption) *File {
 	}
 }
 
-func (f *File) policyPath() string {
+func (f *File) PolicyManager() (redtape.ContextPolicyManager, error) {
+	if !fileExists(f.PolicyPath()) {
+		if err := os.WriteFile(f.PolicyPath(), []byte("{}"), os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	return &filePolicyMgr{mgr: f}, nil
+}
+
+func (f *File) PolicyPath() string {
 	fn := fmt.Sprintf("%s.policy", f.options.Name)
 	return filepath.Join(f.options.Path, fn)
 }
 
-func (f *File) loadPolicies() (map[string]redtape.Policy, error) {
-	b, err := os.ReadFile(f.policyPath())
+func (f *File) loadPolicies(ctx context.Context) (map[string]redtape.Policy, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b, err := f.read(f.PolicyPath())
 	if err != nil {
 		return nil, err
 	}
 
-	m := make(map[string]redtape.Policy)
-	if err := json.Unmarshal(b, &m); err != nil {
+	opts := make(map[string]redtape.PolicyOptions)
+	if err := json.Unmarshal(b, &opts); err != nil {
 		return nil, err
 	}
 
+	m := make(map[string]redtape.Policy, len(opts))
+	for id, o := range opts {
+		p, err := redtape.NewPolicy(redtape.SetPolicyOptions(o), redtape.SetConditionRegistry(f.options.Registry))
+		if err != nil {
+			return nil, err
+		}
+
+		m[id] = p
+	}
+
 	return m, nil
 }
 
-func (f *File) savePolicies(m map[string]redtape.Policy) error {
+func (f *File) savePolicies(ctx context.Context, m map[string]redtape.Policy) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	b, err := json.Marshal(m)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(f.policyPath(), b, os.ModePerm)
+	return f.write(f.PolicyPath(), b)
+}
+
+func (f *File) read(path string) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return os.ReadFile(path)
+}
+
+func (f *File) write(path string, b []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return os.WriteFile(path, b, os.ModePerm)
 }
 
 func (f *File) RoleManager() (redtape.RoleManager, error) {
@@ -74,7 +144,7 @@ func (f *File) RoleManager() (redtape.RoleManager, error) {
 		}
 	}
 
-	return &fileRoleMgr{f}, nil
+	return &fileRoleMgr{mgr: f}, nil
 }
 
 func (f *File) RolePath() string {
@@ -82,8 +152,12 @@ func (f *File) RolePath() string {
 	return filepath.Join(f.options.Path, fn)
 }
 
-func (f *File) loadRoles() (map[string]*redtape.Role, error) {
-	b, err := os.ReadFile(f.RolePath())
+func (f *File) loadRoles(ctx context.Context) (map[string]*redtape.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b, err := f.read(f.RolePath())
 	if err != nil {
 		return nil, err
 	}
@@ -96,29 +170,104 @@ func (f *File) loadRoles() (map[string]*redtape.Role, error) {
 	return m, nil
 }
 
-func (f *File) saveRoles(roles map[string]*redtape.Role) error {
+func (f *File) saveRoles(ctx context.Context, roles map[string]*redtape.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	b, err := json.Marshal(roles)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(f.RolePath(), b, os.ModePerm)
+	return f.write(f.RolePath(), b)
+}
+
+func (f *File) RoleBindingManager() (redtape.ContextRoleBindingManager, error) {
+	if !fileExists(f.BindingPath()) {
+		if err := os.WriteFile(f.BindingPath(), []byte("[]"), os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	return &fileBindingMgr{f}, nil
+}
+
+func (f *File) BindingPath() string {
+	fn := fmt.Sprintf("%s.bindings", f.options.Name)
+	return filepath.Join(f.options.Path, fn)
+}
+
+func (f *File) loadBindings(ctx context.Context) ([]redtape.RoleBinding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b, err := f.read(f.BindingPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []redtape.RoleBinding
+	if err := json.Unmarshal(b, &bindings); err != nil {
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+func (f *File) saveBindings(ctx context.Context, bindings []redtape.RoleBinding) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(bindings)
+	if err != nil {
+		return err
+	}
+
+	return f.write(f.BindingPath(), b)
 }
 
 type fileRoleMgr struct {
+	redtape.RoleListeners
+
 	mgr *File
 }
 
 func (f *fileRoleMgr) Create(role *redtape.Role) error {
-	return f.writeRole(role, false)
+	return f.CreateContext(context.Background(), role)
+}
+
+func (f *fileRoleMgr) CreateContext(ctx context.Context, role *redtape.Role) error {
+	if err := f.writeRole(ctx, role, false); err != nil {
+		return err
+	}
+
+	f.NotifyRoles(redtape.RoleCreated, role.ID)
+
+	return nil
 }
 
 func (f *fileRoleMgr) Update(role *redtape.Role) error {
-	return f.writeRole(role, true)
+	return f.UpdateContext(context.Background(), role)
+}
+
+func (f *fileRoleMgr) UpdateContext(ctx context.Context, role *redtape.Role) error {
+	if err := f.writeRole(ctx, role, true); err != nil {
+		return err
+	}
+
+	f.NotifyRoles(redtape.RoleUpdated, role.ID)
+
+	return nil
 }
 
-func (f *fileRoleMgr) writeRole(role *redtape.Role, overwrite bool) error {
-	m, err := f.mgr.loadRoles()
+func (f *fileRoleMgr) writeRole(ctx context.Context, role *redtape.Role, overwrite bool) error {
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	m, err := f.mgr.loadRoles(ctx)
 	if err != nil {
 		return err
 	}
@@ -128,27 +277,44 @@ func (f *fileRoleMgr) writeRole(role *redtape.Role, overwrite bool) error {
 		return fmt.Errorf("role %s already registered", role.ID)
 	}
 
+	roles := make([]*redtape.Role, 0, len(m))
+	for _, r := range m {
+		roles = append(roles, r)
+	}
+
+	if err := redtape.CheckRoleGraph(role, roles...); err != nil {
+		return err
+	}
+
 	m[role.ID] = role
 
-	return f.mgr.saveRoles(m)
+	return f.mgr.saveRoles(ctx, m)
 }
 
 func (f *fileRoleMgr) Get(id string) (*redtape.Role, error) {
-	m, err := f.mgr.loadRoles()
+	return f.GetContext(context.Background(), id)
+}
+
+func (f *fileRoleMgr) GetContext(ctx context.Context, id string) (*redtape.Role, error) {
+	m, err := f.mgr.loadRoles(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	r, ok := m[id]
 	if !ok {
-		return nil, fmt.Errorf("role %s not found", id)
+		return nil, fmt.Errorf("%w: %s", redtape.ErrRoleNotFound, id)
 	}
 
 	return r, nil
 }
 
 func (f *fileRoleMgr) GetByName(name string) (*redtape.Role, error) {
-	m, err := f.mgr.loadRoles()
+	return f.GetByNameContext(context.Background(), name)
+}
+
+func (f *fileRoleMgr) GetByNameContext(ctx context.Context, name string) (*redtape.Role, error) {
+	m, err := f.mgr.loadRoles(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -163,18 +329,35 @@ func (f *fileRoleMgr) GetByName(name string) (*redtape.Role, error) {
 }
 
 func (f *fileRoleMgr) Delete(id string) error {
-	m, err := f.mgr.loadRoles()
+	return f.DeleteContext(context.Background(), id)
+}
+
+func (f *fileRoleMgr) DeleteContext(ctx context.Context, id string) error {
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	m, err := f.mgr.loadRoles(ctx)
 	if err != nil {
 		return err
 	}
 
 	delete(m, id)
 
-	return f.mgr.saveRoles(m)
+	if err := f.mgr.saveRoles(ctx, m); err != nil {
+		return err
+	}
+
+	f.NotifyRoles(redtape.RoleDeleted, id)
+
+	return nil
 }
 
 func (f *fileRoleMgr) All(limit, offset int) ([]*redtape.Role, error) {
-	m, err := f.mgr.loadRoles()
+	return f.AllContext(context.Background(), limit, offset)
+}
+
+func (f *fileRoleMgr) AllContext(ctx context.Context, limit, offset int) ([]*redtape.Role, error) {
+	m, err := f.mgr.loadRoles(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -197,48 +380,290 @@ func (f *fileRoleMgr) All(limit, offset int) ([]*redtape.Role, error) {
 	return roles, nil
 }
 
-func (f *fileRoleMgr) GetMatching(_ string) ([]*redtape.Role, error) {
-	panic("not implemented") // TODO: Implement
+func (f *fileRoleMgr) GetMatching(pattern string, limit, offset int) ([]*redtape.Role, error) {
+	return f.GetMatchingContext(context.Background(), pattern, limit, offset)
+}
+
+func (f *fileRoleMgr) GetMatchingContext(ctx context.Context, pattern string, limit, offset int) ([]*redtape.Role, error) {
+	m, err := f.mgr.loadRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*redtape.Role, 0, len(m))
+	for _, r := range m {
+		roles = append(roles, r)
+	}
+
+	return redtape.MatchRoles(f.mgr.options.Matcher, roles, pattern, limit, offset)
 }
 
 type filePolicyMgr struct {
+	redtape.PolicyListeners
+
 	mgr *File
 }
 
 func (f *filePolicyMgr) Create(p redtape.Policy) error {
-	panic("not implemented") // TODO: Implement
+	return f.CreateContext(context.Background(), p)
 }
 
-func (f *filePolicyMgr) Update(_ redtape.Policy) error {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) CreateContext(ctx context.Context, p redtape.Policy) error {
+	if err := f.writePolicy(ctx, p, false); err != nil {
+		return err
+	}
+
+	f.Notify(redtape.PolicyCreated, p.ID())
+
+	return nil
 }
 
-func (f *filePolicyMgr) Get(_ string) (redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) Update(p redtape.Policy) error {
+	return f.UpdateContext(context.Background(), p)
 }
 
-func (f *filePolicyMgr) Delete(_ string) error {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) UpdateContext(ctx context.Context, p redtape.Policy) error {
+	if err := f.writePolicy(ctx, p, true); err != nil {
+		return err
+	}
+
+	f.Notify(redtape.PolicyUpdated, p.ID())
+
+	return nil
+}
+
+func (f *filePolicyMgr) writePolicy(ctx context.Context, p redtape.Policy, overwrite bool) error {
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	m, err := f.mgr.loadPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, ok := m[p.ID()]
+	if ok && !overwrite {
+		return fmt.Errorf("policy %s already registered", p.ID())
+	}
+
+	m[p.ID()] = p
+
+	return f.mgr.savePolicies(ctx, m)
+}
+
+func (f *filePolicyMgr) Get(id string) (redtape.Policy, error) {
+	return f.GetContext(context.Background(), id)
+}
+
+func (f *filePolicyMgr) GetContext(ctx context.Context, id string) (redtape.Policy, error) {
+	m, err := f.mgr.loadPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := m[id]
+	if !ok {
+		return nil, fmt.Errorf("policy %s not found", id)
+	}
+
+	return p, nil
+}
+
+func (f *filePolicyMgr) Delete(id string) error {
+	return f.DeleteContext(context.Background(), id)
+}
+
+func (f *filePolicyMgr) DeleteContext(ctx context.Context, id string) error {
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	m, err := f.mgr.loadPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	delete(m, id)
+
+	if err := f.mgr.savePolicies(ctx, m); err != nil {
+		return err
+	}
+
+	f.Notify(redtape.PolicyDeleted, id)
+
+	return nil
 }
 
 func (f *filePolicyMgr) All(limit int, offset int) ([]redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+	return f.AllContext(context.Background(), limit, offset)
+}
+
+func (f *filePolicyMgr) AllContext(ctx context.Context, limit int, offset int) ([]redtape.Policy, error) {
+	pols, err := f.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := limitIndices(limit, offset, len(pols))
+
+	return pols[start:end], nil
+}
+
+func (f *filePolicyMgr) findAll(ctx context.Context) ([]redtape.Policy, error) {
+	m, err := f.mgr.loadPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pols := make([]redtape.Policy, 0, len(m))
+	for _, p := range m {
+		pols = append(pols, p)
+	}
+
+	redtape.SortPolicies(pols)
+
+	return pols, nil
+}
+
+func (f *filePolicyMgr) FindExpired(at time.Time) ([]redtape.Policy, error) {
+	return f.FindExpiredContext(context.Background(), at)
 }
 
-func (f *filePolicyMgr) FindByRequest(_ *redtape.Request) ([]redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) FindExpiredContext(ctx context.Context, at time.Time) ([]redtape.Policy, error) {
+	pols, err := f.findAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := []redtape.Policy{}
+	for _, p := range pols {
+		if p.Validity().Expired(at) {
+			expired = append(expired, p)
+		}
+	}
+
+	return expired, nil
 }
 
-func (f *filePolicyMgr) FindByRole(_ string) ([]redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) FindByRequest(r *redtape.Request) ([]redtape.Policy, error) {
+	return f.FindByRequestContext(context.Background(), r)
 }
 
-func (f *filePolicyMgr) FindByResource(_ string) ([]redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) FindByRequestContext(ctx context.Context, _ *redtape.Request) ([]redtape.Policy, error) {
+	return f.findAll(ctx)
 }
 
-func (f *filePolicyMgr) FindByScope(_ string) ([]redtape.Policy, error) {
-	panic("not implemented") // TODO: Implement
+func (f *filePolicyMgr) FindByRole(role string) ([]redtape.Policy, error) {
+	return f.FindByRoleContext(context.Background(), role)
+}
+
+func (f *filePolicyMgr) FindByRoleContext(ctx context.Context, _ string) ([]redtape.Policy, error) {
+	return f.findAll(ctx)
+}
+
+func (f *filePolicyMgr) FindByResource(res string) ([]redtape.Policy, error) {
+	return f.FindByResourceContext(context.Background(), res)
+}
+
+func (f *filePolicyMgr) FindByResourceContext(ctx context.Context, _ string) ([]redtape.Policy, error) {
+	return f.findAll(ctx)
+}
+
+func (f *filePolicyMgr) FindByScope(scope string) ([]redtape.Policy, error) {
+	return f.FindByScopeContext(context.Background(), scope)
+}
+
+func (f *filePolicyMgr) FindByScopeContext(ctx context.Context, _ string) ([]redtape.Policy, error) {
+	return f.findAll(ctx)
+}
+
+type fileBindingMgr struct {
+	mgr *File
+}
+
+func (f *fileBindingMgr) Bind(b redtape.RoleBinding) error {
+	return f.BindContext(context.Background(), b)
+}
+
+func (f *fileBindingMgr) BindContext(ctx context.Context, b redtape.RoleBinding) error {
+	if b.SubjectID == "" || b.RoleID == "" {
+		return errors.New("role binding requires a subject and role")
+	}
+
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	bindings, err := f.mgr.loadBindings(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := indexBinding(bindings, b.SubjectID, b.RoleID)
+	if i < 0 {
+		bindings = append(bindings, b)
+	} else {
+		bindings[i] = b
+	}
+
+	return f.mgr.saveBindings(ctx, bindings)
+}
+
+func (f *fileBindingMgr) Unbind(subject, role string) error {
+	return f.UnbindContext(context.Background(), subject, role)
+}
+
+func (f *fileBindingMgr) UnbindContext(ctx context.Context, subject, role string) error {
+	f.mgr.updateMu.Lock()
+	defer f.mgr.updateMu.Unlock()
+
+	bindings, err := f.mgr.loadBindings(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := indexBinding(bindings, subject, role)
+	if i < 0 {
+		return fmt.Errorf("subject %s is not bound to role %s", subject, role)
+	}
+
+	bindings = append(bindings[:i], bindings[i+1:]...)
+
+	return f.mgr.saveBindings(ctx, bindings)
+}
+
+func (f *fileBindingMgr) RolesFor(subject string, at time.Time) ([]redtape.RoleBinding, error) {
+	return f.RolesForContext(context.Background(), subject, at)
+}
+
+func (f *fileBindingMgr) RolesForContext(ctx context.Context, subject string, at time.Time) ([]redtape.RoleBinding, error) {
+	bindings, err := f.mgr.loadBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return redtape.FilterRoleBindings(bindings, subject, "", at), nil
+}
+
+func (f *fileBindingMgr) SubjectsFor(role string, at time.Time) ([]redtape.RoleBinding, error) {
+	return f.SubjectsForContext(context.Background(), role, at)
+}
+
+func (f *fileBindingMgr) SubjectsForContext(ctx context.Context, role string, at time.Time) ([]redtape.RoleBinding, error) {
+	bindings, err := f.mgr.loadBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return redtape.FilterRoleBindings(bindings, "", role, at), nil
+}
+
+func indexBinding(bindings []redtape.RoleBinding, subject, role string) int {
+	for i, b := range bindings {
+		if b.SubjectID == subject && b.RoleID == role {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func limitIndices(limit, offset, length int) (int, int) {
@@ -1,8 +1,11 @@
 package manager_test
 
 import (
+	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/blushft/redtape"
 	"github.com/blushft/redtape/manager"
@@ -47,7 +50,189 @@ func TestFileRoleManager(t *testing.T) {
 
 	assert.Len(t, all, 1)
 
+	if err := rm.Create(redtape.NewRole("admin", redtape.NewRole("test_role"))); err != nil {
+		t.Fatal(err)
+	}
+
+	err = rm.Update(redtape.NewRole("test_role", redtape.NewRole("admin")))
+	assert.ErrorIs(t, err, redtape.ErrRoleCycle)
+
+	matching, err := rm.GetMatching("test_*", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, matching, 1) {
+		assert.Equal(t, r.ID, matching[0].ID)
+	}
+
 	if err := os.Remove(f.RolePath()); err != nil {
 		t.Fatal(err)
 	}
 }
+
+func TestFilePolicyManager(t *testing.T) {
+	f := manager.NewFile()
+	pm, err := f.PolicyManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(f.PolicyPath())
+
+	low := redtape.MustNewPolicy(
+		redtape.PolicyID("a_low"),
+		redtape.SetResources("*"),
+		redtape.SetActions("read"),
+		redtape.WithRole(redtape.NewRole("user")),
+		redtape.PolicyAllow(),
+	)
+
+	high := redtape.MustNewPolicy(
+		redtape.PolicyID("b_high"),
+		redtape.SetResources("/secret/*"),
+		redtape.SetActions("read"),
+		redtape.WithRole(redtape.NewRole("user")),
+		redtape.PolicyDeny(),
+		redtape.SetPriority(10),
+	)
+
+	for _, p := range []redtape.Policy{low, high} {
+		if err := pm.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Error(t, pm.Create(low))
+
+	p, err := pm.Get("b_high")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 10, p.Priority())
+	assert.Equal(t, redtape.PolicyEffectDeny, p.Effect())
+
+	all, err := pm.All(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, "b_high", all[0].ID())
+		assert.Equal(t, "a_low", all[1].ID())
+	}
+
+	if err := pm.Delete("a_low"); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err = pm.All(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, all, 1)
+}
+
+func TestFilePolicyManagerConcurrent(t *testing.T) {
+	f := manager.NewFile(func(o *manager.FileOptions) {
+		o.Name = "concurrent"
+		o.Path = t.TempDir()
+	})
+
+	pm, err := f.PolicyManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 20
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 2*n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			errs <- pm.Create(redtape.MustNewPolicy(
+				redtape.PolicyID(fmt.Sprintf("policy_%d", i)),
+				redtape.SetResources("*"),
+				redtape.SetActions("read"),
+				redtape.PolicyAllow(),
+			))
+
+			_, err := pm.All(n, 0)
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := pm.All(2*n, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, all, n)
+}
+
+func TestFileRoleBindingManager(t *testing.T) {
+	f := manager.NewFile()
+	bm, err := f.RoleBindingManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(f.BindingPath())
+
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	if err := bm.Bind(redtape.NewRoleBinding("alice", "editor", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bm.Bind(redtape.NewRoleBinding("alice", "oncall", &expires)); err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := bm.RolesFor("alice", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, roles, 2)
+
+	roles, err = bm.RolesFor("alice", expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, roles, 1) {
+		assert.Equal(t, "editor", roles[0].RoleID)
+	}
+
+	subjects, err := bm.SubjectsFor("editor", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, subjects, 1)
+
+	if err := bm.Unbind("alice", "editor"); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, bm.Unbind("alice", "editor"))
+}
@@ -0,0 +1,52 @@
+package redtape
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleManagerGetMatching(t *testing.T) {
+	ids := []string{"admin", "comments_edit", "comments_view", "posts_edit", "posts_view"}
+
+	simple := NewRoleManager()
+	regex := NewRoleManager(SetRoleMatcher(NewRegexMatcher()))
+
+	for _, id := range ids {
+		for _, rm := range []RoleManager{simple, regex} {
+			if err := rm.Create(NewRole(id)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rm      RoleManager
+		pattern string
+		limit   int
+		offset  int
+		want    []string
+	}{
+		{"exact", simple, "admin", 10, 0, []string{"admin"}},
+		{"wildcard", simple, "*_edit", 10, 0, []string{"comments_edit", "posts_edit"}},
+		{"all", simple, "*", 10, 0, ids},
+		{"page", simple, "*", 2, 2, []string{"comments_view", "posts_edit"}},
+		{"past_end", simple, "*_view", 10, 5, []string{}},
+		{"none", simple, "billing*", 10, 0, []string{}},
+		{"regex", regex, "<(comments|posts)_view>", 10, 0, []string{"comments_view", "posts_view"}},
+		{"regex_page", regex, "posts_<.+>", 1, 1, []string{"posts_view"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := tt.rm.GetMatching(tt.pattern, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := roleIDs(roles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetMatching(%q, %d, %d) = %v, want %v", tt.pattern, tt.limit, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
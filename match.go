@@ -1,8 +1,10 @@
 package redtape
 
 import (
+	"container/list"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/blushft/redtape/strmatch"
 )
@@ -52,18 +54,33 @@ func (m *simpleMatcher) MatchRole(r *Role, val string) (bool, error) {
 	return false, nil
 }
 
+// regexCacheSize bounds the number of compiled patterns kept by a regex Matcher. Patterns expanded from
+// request templates may be unique per request, so the least recently used patterns are evicted.
+const regexCacheSize = 512
+
+type regexEntry struct {
+	pattern string
+	reg     *regexp.Regexp
+}
+
 type regexMatcher struct {
 	startDelim string
 	stopDelim  string
-	pat        map[string]*regexp.Regexp
+	size       int
+	pat        map[string]*list.Element
+	lru        *list.List
+	mu         sync.Mutex
 }
 
-// NewRegexMatcher returns a Matcher using delimited regex for matching.
+// NewRegexMatcher returns a Matcher using delimited regex for matching. Compiled patterns are cached up to
+// a fixed number of patterns.
 func NewRegexMatcher() Matcher {
 	return &regexMatcher{
 		startDelim: "<",
 		stopDelim:  ">",
-		pat:        make(map[string]*regexp.Regexp),
+		size:       regexCacheSize,
+		pat:        make(map[string]*list.Element),
+		lru:        list.New(),
 	}
 }
 
@@ -97,17 +114,9 @@ func (m *regexMatcher) match(def []string, val string) (bool, error) {
 			continue
 		}
 
-		var reg *regexp.Regexp
-		var err error
-
-		reg, ok := m.pat[h]
-		if !ok {
-			reg, err = strmatch.CompileDelimitedRegex(val, '<', '>')
-			if err != nil {
-				return false, err
-			}
-
-			m.pat[h] = reg
+		reg, err := m.compile(h)
+		if err != nil {
+			return false, err
 		}
 
 		if reg.MatchString(val) {
@@ -117,3 +126,38 @@ func (m *regexMatcher) match(def []string, val string) (bool, error) {
 
 	return false, nil
 }
+
+// compile returns the compiled regex of the delimited pattern h, evicting the least recently used pattern
+// once the cache is full.
+func (m *regexMatcher) compile(h string) (*regexp.Regexp, error) {
+	m.mu.Lock()
+	if el, ok := m.pat[h]; ok {
+		m.lru.MoveToFront(el)
+		m.mu.Unlock()
+
+		return el.Value.(*regexEntry).reg, nil
+	}
+	m.mu.Unlock()
+
+	reg, err := strmatch.CompileDelimitedRegex(h, '<', '>')
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pat[h]; ok {
+		return reg, nil
+	}
+
+	m.pat[h] = m.lru.PushFront(&regexEntry{pattern: h, reg: reg})
+
+	for m.lru.Len() > m.size {
+		last := m.lru.Back()
+		m.lru.Remove(last)
+		delete(m.pat, last.Value.(*regexEntry).pattern)
+	}
+
+	return reg, nil
+}
@@ -0,0 +1,80 @@
+package redtape
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegexMatcher(t *testing.T) {
+	tests := []struct {
+		name string
+		def  []string
+		val  string
+		want bool
+	}{
+		{"regex", []string{"/docs/<[0-9]+>"}, "/docs/12", true},
+		{"regex_mismatch", []string{"/docs/<[0-9]+>"}, "/docs/readme", false},
+		{"regex_prefix", []string{"/docs/<[0-9]+>"}, "/users/12", false},
+		{"wildcard", []string{"/docs/*"}, "/docs/readme", true},
+		{"wildcard_mismatch", []string{"/docs/*"}, "/users/1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRegexMatcher().MatchPolicy(nil, tt.def, tt.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("MatchPolicy(%v, %q) = %v, want %v", tt.def, tt.val, got, tt.want)
+			}
+		})
+	}
+
+	// patterns are cached by definition and must keep matching other values
+	m := NewRegexMatcher()
+
+	for _, val := range []string{"/docs/12", "/docs/13"} {
+		got, err := m.MatchPolicy(nil, []string{"/docs/<[0-9]+>"}, val)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got {
+			t.Errorf("MatchPolicy(%q) = false after caching the pattern", val)
+		}
+	}
+}
+
+func TestRegexMatcherCache(t *testing.T) {
+	m := NewRegexMatcher().(*regexMatcher)
+
+	for i := 0; i < regexCacheSize*2; i++ {
+		match, err := m.MatchPolicy(nil, []string{fmt.Sprintf("/users/%d/<[0-9]+>", i)}, fmt.Sprintf("/users/%d/1", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !match {
+			t.Fatalf("MatchPolicy() = false for pattern %d", i)
+		}
+
+		// keep the first pattern in use
+		if _, err := m.MatchPolicy(nil, []string{"/users/0/<[0-9]+>"}, "/users/0/1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(m.pat) != regexCacheSize || m.lru.Len() != regexCacheSize {
+		t.Errorf("cache holds %d patterns and %d entries, want %d", len(m.pat), m.lru.Len(), regexCacheSize)
+	}
+
+	if _, ok := m.pat["/users/0/<[0-9]+>"]; !ok {
+		t.Error("recently used pattern was evicted")
+	}
+
+	if _, ok := m.pat["/users/1/<[0-9]+>"]; ok {
+		t.Error("least recently used pattern was retained")
+	}
+}
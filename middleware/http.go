@@ -11,7 +11,13 @@ func NewHTTPMiddleware(e redtape.Enforcer, h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		req := redtape.NewRequestWithContext(r.Context(), r.URL.Path, r.Method, "", "", requestMetadata(r))
 
-		if err := e.Enforce(req); err != nil {
+		d, err := e.Decide(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := d.Err(); err != nil {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -0,0 +1,54 @@
+package redtape
+
+// Obligation is a typed key/value payload a policy attaches to its effect. Obligations declared by a policy
+// must be fulfilled by a registered ObligationHandler, while advice uses the same payload as an optional hint
+// returned to the caller.
+type Obligation struct {
+	Type   string                 `json:"type"`
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// NewObligation returns an Obligation of type t carrying values.
+func NewObligation(t string, values map[string]interface{}) Obligation {
+	return Obligation{
+		Type:   t,
+		Values: values,
+	}
+}
+
+// ObligationHandler is a typed function fulfilling an Obligation for a request. A returned error marks the
+// obligation as unfulfilled.
+type ObligationHandler func(req *Request, o Obligation) error
+
+// collectObligations returns the obligations and advice of every matched policy sharing effect.
+func collectObligations(matched []Policy, effect PolicyEffect) ([]Obligation, []Obligation) {
+	var obs, adv []Obligation
+
+	for _, p := range matched {
+		if p.Effect() != effect {
+			continue
+		}
+
+		obs = append(obs, p.Obligations()...)
+		adv = append(adv, p.Advice()...)
+	}
+
+	return obs, adv
+}
+
+// fulfill runs the handler registered for each obligation of d. Obligations without a handler or with a
+// failing handler are recorded in Decision#Unfulfilled and the decision is denied.
+func fulfill(handlers map[string]ObligationHandler, r *Request, d *Decision) {
+	for _, o := range d.Obligations {
+		h, ok := handlers[o.Type]
+		if !ok || h(r, o) != nil {
+			d.Unfulfilled = append(d.Unfulfilled, o)
+		}
+	}
+
+	if len(d.Unfulfilled) > 0 {
+		d.Effect = PolicyEffectDeny
+		d.Explicit = false
+		d.Policy = nil
+	}
+}
@@ -0,0 +1,172 @@
+package redtape
+
+import (
+	"time"
+
+	"github.com/blushft/redtape/strmatch"
+)
+
+// ResourcePattern is a residual resource pattern left by partial evaluation. Conditions holds the names of
+// the policy conditions that could not be resolved without a concrete request.
+type ResourcePattern struct {
+	Pattern    string   `json:"pattern"`
+	PolicyID   string   `json:"policy_id"`
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+// Conditional returns true when the pattern only applies if its conditions are met.
+func (p ResourcePattern) Conditional() bool {
+	return len(p.Conditions) > 0
+}
+
+// Like returns the pattern translated to a SQL LIKE expression using '\' as the escape character.
+func (p ResourcePattern) Like() string {
+	return strmatch.WildcardToLike(p.Pattern, '\\')
+}
+
+// ResourceFilter describes the resources a partially evaluated request may access using deny-overrides
+// semantics: a resource is permitted when it matches an Allow pattern and no Deny pattern. Resources that
+// match no Allow pattern are denied.
+type ResourceFilter struct {
+	Allow []ResourcePattern `json:"allow"`
+	Deny  []ResourcePattern `json:"deny"`
+}
+
+// Allows evaluates a concrete resource against the filter with m. Unresolved conditions fail closed:
+// conditional allow patterns never permit a resource and conditional deny patterns always deny it.
+func (f *ResourceFilter) Allows(m Matcher, resource string) (bool, error) {
+	for _, d := range f.Deny {
+		match, err := m.MatchPolicy(nil, []string{d.Pattern}, resource)
+		if err != nil {
+			return false, err
+		}
+
+		if match {
+			return false, nil
+		}
+	}
+
+	for _, a := range f.Allow {
+		if a.Conditional() {
+			continue
+		}
+
+		match, err := m.MatchPolicy(nil, []string{a.Pattern}, resource)
+		if err != nil {
+			return false, err
+		}
+
+		if match {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// EvalOptions configures the policy set evaluations PartialEval and RoleGrants.
+type EvalOptions struct {
+	Clock func() time.Time
+}
+
+// EvalOption is a typed function allowing updates to EvalOptions through functional options.
+type EvalOption func(*EvalOptions)
+
+// SetEvalClock sets the function returning the current time used to check policy validity windows. time.Now
+// is used when it is not set.
+func SetEvalClock(now func() time.Time) EvalOption {
+	return func(o *EvalOptions) {
+		o.Clock = now
+	}
+}
+
+// NewEvalOptions returns EvalOptions configured with the provided options.
+func NewEvalOptions(opts ...EvalOption) EvalOptions {
+	o := EvalOptions{
+		Clock: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.Clock == nil {
+		o.Clock = time.Now
+	}
+
+	return o
+}
+
+// PartialEval evaluates every policy stored in pm against the action, role and scope of r, leaving the
+// resource open. The resource patterns of matching policies are returned as a ResourceFilter that can be
+// translated into a data query. r.Resource is ignored and policy conditions are reported as unresolved
+// rather than evaluated, while template variables are substituted from r. Shadow policies and policies
+// outside their validity window at the time of the configured clock are ignored. The request context is
+// passed to pm when it implements ContextPolicyManager.
+func PartialEval(pm PolicyManager, m Matcher, r *Request, opts ...EvalOption) (*ResourceFilter, error) {
+	o := NewEvalOptions(opts...)
+
+	pols, err := allPolicies(r.ctx(), pm)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &ResourceFilter{
+		Allow: []ResourcePattern{},
+		Deny:  []ResourcePattern{},
+	}
+
+	now := o.Clock()
+
+	for _, p := range pols {
+		if p.Mode() == PolicyModeShadow || !p.Validity().Active(now) {
+			continue
+		}
+
+		match, err := partialMatch(m, p, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if !match {
+			continue
+		}
+
+		conds := p.Conditions().Names()
+
+		for _, res := range orAny(expandTemplates(p.Resources(), r)) {
+			rp := ResourcePattern{
+				Pattern:    res,
+				PolicyID:   p.ID(),
+				Conditions: conds,
+			}
+
+			if p.Effect() == PolicyEffectDeny {
+				f.Deny = append(f.Deny, rp)
+			} else {
+				f.Allow = append(f.Allow, rp)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+func partialMatch(m Matcher, p Policy, r *Request) (bool, error) {
+	am, err := m.MatchPolicy(p, expandTemplates(p.Actions(), r), r.Action)
+	if err != nil || !am {
+		return false, err
+	}
+
+	roles, err := r.Roles()
+	if err != nil {
+		return false, err
+	}
+
+	rm, err := matchPrincipal(m, p, r, roles, nil)
+	if err != nil || !rm {
+		return false, err
+	}
+
+	return m.MatchPolicy(p, expandTemplates(p.Scopes(), r), r.Scope)
+}
@@ -0,0 +1,86 @@
+package redtape
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialEval(t *testing.T) {
+	pm := NewManager()
+	expiry := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	pols := []Policy{
+		MustNewPolicy(
+			PolicyID("read_docs"),
+			SetResources("/docs/*", "/shared/*"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("read_drafts"),
+			SetResources("/drafts/*"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			WithCondition(ConditionOptions{Name: "owner", Type: "bool", Options: map[string]interface{}{"value": true}}),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("hide_secret"),
+			SetResources("/docs/secret_*"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			PolicyDeny(),
+		),
+		MustNewPolicy(
+			PolicyID("read_archive"),
+			SetResources("/archive/*"),
+			SetActions("read"),
+			WithRole(NewRole("viewer")),
+			SetNotAfter(expiry),
+			PolicyAllow(),
+		),
+		MustNewPolicy(
+			PolicyID("write_docs"),
+			SetResources("/docs/*"),
+			SetActions("write"),
+			WithRole(NewRole("viewer")),
+			PolicyAllow(),
+		),
+	}
+
+	for _, p := range pols {
+		require.NoError(t, pm.Create(p))
+	}
+
+	m := NewMatcher()
+
+	f, err := PartialEval(pm, m, NewRequest("", "read", "viewer", ""))
+	require.NoError(t, err)
+	require.Len(t, f.Allow, 3)
+	require.Len(t, f.Deny, 1)
+
+	assert.Equal(t, "/docs/secret\\_%", f.Deny[0].Like())
+
+	for res, want := range map[string]bool{
+		"/docs/readme":     true,
+		"/docs/secret_key": false,
+		"/drafts/plan":     false,
+		"/other":           false,
+	} {
+		got, err := f.Allows(m, res)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, res)
+	}
+
+	f, err = PartialEval(pm, m, NewRequest("", "read", "viewer", ""), SetEvalClock(func() time.Time { return expiry.Add(-time.Hour) }))
+	require.NoError(t, err)
+	require.Len(t, f.Allow, 4)
+
+	got, err := f.Allows(m, "/archive/2020")
+	require.NoError(t, err)
+	assert.True(t, got)
+}
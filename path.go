@@ -0,0 +1,188 @@
+package redtape
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	pathMeta        = "meta."
+	pathSubjectAttr = "subject.attr."
+)
+
+// requestPaths resolve the fixed paths of Request#Lookup.
+var requestPaths = map[string]func(r *Request) (interface{}, bool){
+	"subject.id":       func(r *Request) (interface{}, bool) { return r.SubjectID(), true },
+	"request.resource": func(r *Request) (interface{}, bool) { return r.Resource, true },
+	"request.action":   func(r *Request) (interface{}, bool) { return r.Action, true },
+	"request.role":     func(r *Request) (interface{}, bool) { return r.Role, true },
+	"request.scope":    func(r *Request) (interface{}, bool) { return r.Scope, true },
+	"request.roles": func(r *Request) (interface{}, bool) {
+		roles, err := r.Roles()
+		return roles, err == nil
+	},
+}
+
+// ValidatePath returns an error when path can not be resolved by Request#Lookup.
+func ValidatePath(path string) error {
+	if _, ok := requestPaths[path]; ok {
+		return nil
+	}
+
+	for _, prefix := range []string{pathMeta, pathSubjectAttr} {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		for _, seg := range strings.Split(strings.TrimPrefix(path, prefix), ".") {
+			if seg == "" {
+				return fmt.Errorf("empty segment in path %q", path)
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown path %q", path)
+}
+
+// Lookup returns the request value found at path:
+//
+//	subject.id            the request subject ID, see Request#SubjectID
+//	subject.attr.<path>   a value nested in the Subject attributes
+//	request.resource      the request resource, and likewise action, role and scope
+//	request.roles         the request role and the effective roles of the Subject
+//	meta.<path>           a value nested in the RequestMetadata, see RequestMetadata#Lookup
+//
+// It returns false when the path is unknown or no value is set.
+func (r *Request) Lookup(path string) (interface{}, bool) {
+	if fn, ok := requestPaths[path]; ok {
+		return fn(r)
+	}
+
+	switch {
+	case strings.HasPrefix(path, pathMeta):
+		return r.Metadata().Lookup(strings.TrimPrefix(path, pathMeta))
+	case strings.HasPrefix(path, pathSubjectAttr) && r.Subject != nil:
+		return LookupPath(r.Subject.Attributes, strings.TrimPrefix(path, pathSubjectAttr))
+	default:
+		return nil, false
+	}
+}
+
+// Lookup returns the metadata value found at the dotted path, see LookupPath.
+func (m RequestMetadata) Lookup(path string) (interface{}, bool) {
+	return LookupPath(map[string]interface{}(m), path)
+}
+
+// LookupPath returns the value found by following the dotted path through v. Each segment selects a map
+// key, a slice or array index, or a struct field by its json tag or name. Pointers and interfaces are followed
+// along the way. It returns false when a segment can not be resolved or the value found is nil.
+func LookupPath(v interface{}, path string) (interface{}, bool) {
+	for _, seg := range strings.Split(path, ".") {
+		var ok bool
+
+		switch cur := v.(type) {
+		case map[string]interface{}:
+			v, ok = cur[seg]
+		case RequestMetadata:
+			v, ok = cur[seg]
+		case map[string]string:
+			v, ok = cur[seg]
+		case []interface{}:
+			var i int
+			if i, ok = sliceIndex(seg, len(cur)); ok {
+				v = cur[i]
+			}
+		default:
+			v, ok = lookupValue(reflect.ValueOf(v), seg)
+		}
+
+		if !ok || v == nil {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func sliceIndex(seg string, n int) (int, bool) {
+	i, err := strconv.Atoi(seg)
+	return i, err == nil && i >= 0 && i < n
+}
+
+// lookupValue resolves seg on maps with string keys, slices, arrays and structs of any type.
+func lookupValue(rv reflect.Value, seg string) (interface{}, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+
+		rv = rv.Elem()
+	}
+
+	var fv reflect.Value
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+
+		fv = rv.MapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()))
+	case reflect.Slice, reflect.Array:
+		i, ok := sliceIndex(seg, rv.Len())
+		if !ok {
+			return nil, false
+		}
+
+		fv = rv.Index(i)
+	case reflect.Struct:
+		fv = structField(rv, seg)
+	default:
+		return nil, false
+	}
+
+	if !fv.IsValid() || !fv.CanInterface() {
+		return nil, false
+	}
+
+	return fv.Interface(), true
+}
+
+// structField returns the exported field of rv named seg by its json tag, or by its name ignoring case.
+func structField(rv reflect.Value, seg string) reflect.Value {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name == seg {
+			return rv.Field(i)
+		}
+	}
+
+	f, ok := rt.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, seg) })
+	if !ok || f.PkgPath != "" {
+		return reflect.Value{}
+	}
+
+	for _, i := range f.Index {
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}
+			}
+
+			rv = rv.Elem()
+		}
+
+		rv = rv.Field(i)
+	}
+
+	return rv
+}
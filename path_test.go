@@ -0,0 +1,119 @@
+package redtape
+
+import (
+	"reflect"
+	"testing"
+)
+
+type pathOwner struct {
+	ID      string `json:"id"`
+	Profile *pathProfile
+	secret  string
+}
+
+type pathProfile struct {
+	Email string
+}
+
+func TestLookupPath(t *testing.T) {
+	v := map[string]interface{}{
+		"owner":  &pathOwner{ID: "alice", Profile: &pathProfile{Email: "alice@example.com"}, secret: "x"},
+		"nobody": (*pathOwner)(nil),
+		"labels": map[string]string{"env": "prod"},
+		"tags":   []interface{}{"a", map[string]interface{}{"b": 2}},
+		"ids":    []int{4, 5},
+		"counts": map[string]int{"open": 3},
+		"meta":   RequestMetadata{"tenant": "acme"},
+		"empty":  nil,
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{"top_level", "labels", map[string]string{"env": "prod"}, true},
+		{"string_map", "labels.env", "prod", true},
+		{"struct_json_tag", "owner.id", "alice", true},
+		{"struct_field_name", "owner.profile.email", "alice@example.com", true},
+		{"unexported_field", "owner.secret", nil, false},
+		{"nil_pointer", "nobody.id", nil, false},
+		{"slice_index", "tags.0", "a", true},
+		{"slice_nested", "tags.1.b", 2, true},
+		{"typed_slice", "ids.1", 5, true},
+		{"index_out_of_range", "ids.2", nil, false},
+		{"typed_map", "counts.open", 3, true},
+		{"metadata", "meta.tenant", "acme", true},
+		{"nil_value", "empty", nil, false},
+		{"missing", "labels.region", nil, false},
+		{"scalar", "labels.env.x", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LookupPath(v, tt.path)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LookupPath(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRequestLookup(t *testing.T) {
+	sub := NewSubject("alice", NewRole("editor", NewRole("viewer")))
+	sub.Attributes["org"] = map[string]interface{}{"id": "acme"}
+
+	r := NewSubjectRequest("/docs/1", "edit", sub, "eu", map[string]interface{}{
+		"document": map[string]interface{}{"owner_id": "alice"},
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{"subject_id", "subject.id", "alice", true},
+		{"resource", "request.resource", "/docs/1", true},
+		{"scope", "request.scope", "eu", true},
+		{"roles", "request.roles", []string{"editor", "viewer"}, true},
+		{"subject_attr", "subject.attr.org.id", "acme", true},
+		{"meta", "meta.document.owner_id", "alice", true},
+		{"meta_missing", "meta.document.editors", nil, false},
+		{"unknown", "request.body", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := r.Lookup(tt.path)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Request.Lookup(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"subject.id", false},
+		{"request.roles", false},
+		{"meta.document.owner_id", false},
+		{"subject.attr.org", false},
+		{"meta.", true},
+		{"meta.a..b", true},
+		{"request.body", true},
+		{"value", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if err := ValidatePath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
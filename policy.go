@@ -3,8 +3,9 @@ package redtape
 import (
 	"context"
 	"encoding/json"
-
-	"github.com/fatih/structs"
+	"fmt"
+	"sort"
+	"time"
 )
 
 // PolicyEffect type is returned by Enforcer to describe the outcome of a policy evaluation.
@@ -29,50 +30,118 @@ func NewPolicyEffect(s string) PolicyEffect {
 	}
 }
 
+// PolicyMode describes whether a policy takes part in enforcement.
+type PolicyMode string
+
+const (
+	// PolicyModeEnforce indicates the policy effect is applied to decisions.
+	PolicyModeEnforce PolicyMode = "enforce"
+	// PolicyModeShadow indicates the policy is evaluated and audited without affecting decisions.
+	PolicyModeShadow PolicyMode = "shadow"
+)
+
+// NewPolicyMode returns a PolicyMode for a given string. An empty string defaults to PolicyModeEnforce and an
+// error is returned for unknown values.
+func NewPolicyMode(s string) (PolicyMode, error) {
+	switch s {
+	case "", "enforce":
+		return PolicyModeEnforce, nil
+	case "shadow":
+		return PolicyModeShadow, nil
+	default:
+		return "", fmt.Errorf("unknown policy mode %q", s)
+	}
+}
+
 // Policy provides methods to return data about a configured policy.
 type Policy interface {
 	ID() string
 	Name() string
 	Description() string
 	Roles() []*Role
+	Subjects() []string
 	Resources() []string
 	Actions() []string
 	Scopes() []string
 	Conditions() Conditions
 	Effect() PolicyEffect
+	Mode() PolicyMode
+	Priority() int
+	Obligations() []Obligation
+	Advice() []Obligation
+	Validity() Validity
 	Context() context.Context
 }
 
 type policy struct {
-	id         string
-	name       string
-	desc       string
-	roles      []*Role
-	resources  []string
-	actions    []string
-	scopes     []string
-	conditions Conditions
-	effect     PolicyEffect
-	ctx        context.Context
-}
-
-// NewPolicy returns a default policy implementation from a set of provided options.
+	id          string
+	name        string
+	desc        string
+	roles       []*Role
+	subjects    []string
+	resources   []string
+	actions     []string
+	scopes      []string
+	conditions  Conditions
+	effect      PolicyEffect
+	mode        PolicyMode
+	priority    int
+	obligations []Obligation
+	advice      []Obligation
+	validity    Validity
+	ctx         context.Context
+}
+
+// NewPolicy returns a default policy implementation from a set of provided options. An error is returned
+// when the options contain an unknown mode or invalid templates, schedules or conditions.
 func NewPolicy(opts ...PolicyOption) (Policy, error) {
 	o := NewPolicyOptions(opts...)
 
+	mode, err := NewPolicyMode(o.Mode)
+	if err != nil {
+		return nil, err
+	}
+
 	p := &policy{
-		id:        o.ID,
-		name:      o.Name,
-		desc:      o.Description,
-		roles:     o.Roles,
-		resources: o.Resources,
-		actions:   o.Actions,
-		scopes:    o.Scopes,
-		effect:    NewPolicyEffect(o.Effect),
-		ctx:       o.Context,
-	}
-
-	conds, err := NewConditions(o.Conditions, nil)
+		id:          o.ID,
+		name:        o.Name,
+		desc:        o.Description,
+		roles:       o.Roles,
+		subjects:    o.Subjects,
+		resources:   o.Resources,
+		actions:     o.Actions,
+		scopes:      o.Scopes,
+		effect:      NewPolicyEffect(o.Effect),
+		mode:        mode,
+		priority:    o.Priority,
+		obligations: o.Obligations,
+		advice:      o.Advice,
+		validity: Validity{
+			NotBefore: o.NotBefore,
+			NotAfter:  o.NotAfter,
+			Schedules: o.Schedules,
+		},
+		ctx: o.Context,
+	}
+
+	if err := validateTemplates(o.Resources, o.Actions, o.Scopes); err != nil {
+		return nil, err
+	}
+
+	if len(o.Schedules) > 0 {
+		p.validity.Schedules = make([]Schedule, 0, len(o.Schedules))
+	}
+
+	for _, sc := range o.Schedules {
+		csc, err := sc.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		p.validity.Schedules = append(p.validity.Schedules, csc)
+	}
+
+	conds, err := NewConditions(o.Conditions, o.Registry)
 	if err != nil {
 		return nil, err
 	}
@@ -100,28 +169,34 @@ func (p *policy) MarshalJSON() ([]byte, error) {
 		Name:        p.name,
 		Description: p.desc,
 		Roles:       p.roles,
+		Subjects:    p.subjects,
 		Resources:   p.resources,
 		Actions:     p.actions,
 		Scopes:      p.scopes,
 		Effect:      string(p.effect),
+		Mode:        string(p.mode),
+		Priority:    p.priority,
+		Obligations: p.obligations,
+		Advice:      p.advice,
+		NotBefore:   p.validity.NotBefore,
+		NotAfter:    p.validity.NotAfter,
+		Schedules:   p.validity.Schedules,
 	}
 
-	structs.DefaultTagName = "json"
+	opts.Conditions = p.conditions.Options()
 
-	copts := make([]ConditionOptions, 0, len(p.conditions))
-	for k, c := range p.conditions {
-		cov := structs.Map(c)
-		co := ConditionOptions{
-			Name:    k,
-			Type:    c.Name(),
-			Options: cov,
-		}
-		copts = append(copts, co)
-	}
+	return json.Marshal(opts)
+}
 
-	opts.Conditions = copts
+// UnmarshalPolicy decodes a JSON policy as produced by Policy#MarshalJSON into a Policy. Conditions are built
+// from reg, falling back to the default ConditionRegistry when reg is nil.
+func UnmarshalPolicy(b []byte, reg ConditionRegistry) (Policy, error) {
+	var o PolicyOptions
+	if err := json.Unmarshal(b, &o); err != nil {
+		return nil, err
+	}
 
-	return json.Marshal(opts)
+	return NewPolicy(SetPolicyOptions(o), SetConditionRegistry(reg))
 }
 
 // ID returns the policy ID.
@@ -144,6 +219,11 @@ func (p *policy) Roles() []*Role {
 	return p.roles
 }
 
+// Subjects returns the subject IDs the policy applies to in addition to its roles.
+func (p *policy) Subjects() []string {
+	return p.subjects
+}
+
 // Resources returns the resources the policy applies to.
 func (p *policy) Resources() []string {
 	return p.resources
@@ -173,17 +253,63 @@ func (p *policy) Effect() PolicyEffect {
 	return p.effect
 }
 
+// Mode returns the configured PolicyMode.
+func (p *policy) Mode() PolicyMode {
+	return p.mode
+}
+
+// Priority returns the policy priority. Higher values are applied first by ordered combining algorithms.
+func (p *policy) Priority() int {
+	return p.priority
+}
+
+// Obligations returns the obligations that must be fulfilled when the policy effect is applied.
+func (p *policy) Obligations() []Obligation {
+	return p.obligations
+}
+
+// Advice returns the optional advice returned when the policy effect is applied.
+func (p *policy) Advice() []Obligation {
+	return p.advice
+}
+
+// Validity returns the time bounds of the policy.
+func (p *policy) Validity() Validity {
+	return p.validity
+}
+
+// SortPolicies orders policies by descending priority, breaking ties by ID, giving the deterministic order
+// policies are evaluated in.
+func SortPolicies(ps []Policy) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].Priority() != ps[j].Priority() {
+			return ps[i].Priority() > ps[j].Priority()
+		}
+
+		return ps[i].ID() < ps[j].ID()
+	})
+}
+
 // PolicyOptions struct allows different Policy implementations to be configured with marshalable data.
 type PolicyOptions struct {
 	ID          string             `json:"id"`
 	Name        string             `json:"name"`
 	Description string             `json:"description"`
 	Roles       []*Role            `json:"roles"`
+	Subjects    []string           `json:"subjects,omitempty"`
 	Resources   []string           `json:"resources"`
 	Actions     []string           `json:"actions"`
 	Scopes      []string           `json:"scopes"`
 	Conditions  []ConditionOptions `json:"conditions"`
 	Effect      string             `json:"effect"`
+	Mode        string             `json:"mode,omitempty"`
+	Priority    int                `json:"priority,omitempty"`
+	Obligations []Obligation       `json:"obligations,omitempty"`
+	Advice      []Obligation       `json:"advice,omitempty"`
+	NotBefore   *time.Time         `json:"not_before,omitempty"`
+	NotAfter    *time.Time         `json:"not_after,omitempty"`
+	Schedules   []Schedule         `json:"schedules,omitempty"`
+	Registry    ConditionRegistry  `json:"-"`
 	Context     context.Context    `json:"-"`
 }
 
@@ -201,7 +327,8 @@ func NewPolicyOptions(opts ...PolicyOption) PolicyOptions {
 	return options
 }
 
-// SetPolicyOptions is a PolicyOption setting all PolicyOptions to the provided values.
+// SetPolicyOptions is a PolicyOption setting all PolicyOptions to the provided values. Options applied before it,
+// including the ConditionRegistry, are replaced.
 func SetPolicyOptions(opts PolicyOptions) PolicyOption {
 	return func(o *PolicyOptions) {
 		*o = opts
@@ -235,6 +362,62 @@ func SetPolicyEffect(s string) PolicyOption {
 	}
 }
 
+// SetPolicyMode sets the policy Mode option.
+func SetPolicyMode(s string) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Mode = s
+	}
+}
+
+// PolicyShadow sets the PolicyMode to shadow. Shadow policies are audited but never affect decisions.
+func PolicyShadow() PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Mode = "shadow"
+	}
+}
+
+// SetPriority sets the policy Priority option.
+func SetPriority(n int) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Priority = n
+	}
+}
+
+// WithObligation adds an Obligation to the Obligations option.
+func WithObligation(ob Obligation) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Obligations = append(o.Obligations, ob)
+	}
+}
+
+// WithAdvice adds an Obligation to the Advice option.
+func WithAdvice(ad Obligation) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Advice = append(o.Advice, ad)
+	}
+}
+
+// SetNotBefore sets the time the policy starts to apply.
+func SetNotBefore(t time.Time) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.NotBefore = &t
+	}
+}
+
+// SetNotAfter sets the time the policy stops to apply.
+func SetNotAfter(t time.Time) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.NotAfter = &t
+	}
+}
+
+// WithSchedule adds a recurring Schedule to the Schedules option.
+func WithSchedule(sc Schedule) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Schedules = append(o.Schedules, sc)
+	}
+}
+
 // PolicyDeny sets the PolicyEffect to deny.
 func PolicyDeny() PolicyOption {
 	return func(o *PolicyOptions) {
@@ -283,9 +466,32 @@ func WithCondition(co ConditionOptions) PolicyOption {
 	}
 }
 
+// SetConditionRegistry sets the ConditionRegistry used to build the Conditions option. The default
+// ConditionRegistry is used when it is not set.
+func SetConditionRegistry(reg ConditionRegistry) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Registry = reg
+	}
+}
+
+// WithSubject adds a subject ID to the Subjects option.
+func WithSubject(id string) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Subjects = append(o.Subjects, id)
+	}
+}
+
 // WithRole adds a Role to the Roles option.
 func WithRole(r *Role) PolicyOption {
 	return func(o *PolicyOptions) {
 		o.Roles = append(o.Roles, r)
 	}
 }
+
+// WithRoleID adds a reference to the role with id to the Roles option. The role definition is resolved at
+// evaluation time by an Enforcer configured with a RoleManager.
+func WithRoleID(id string) PolicyOption {
+	return func(o *PolicyOptions) {
+		o.Roles = append(o.Roles, NewRole(id))
+	}
+}
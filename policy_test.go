@@ -2,6 +2,7 @@ package redtape
 
 import (
 	"context"
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -94,3 +95,119 @@ func Test_policy_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+type tenantCondition struct {
+	Tenant string `json:"tenant"`
+}
+
+func (c *tenantCondition) Name() string {
+	return "tenant"
+}
+
+func (c *tenantCondition) Meets(val interface{}, _ *Request) bool {
+	return val == c.Tenant
+}
+
+func TestUnmarshalPolicy(t *testing.T) {
+	reg := NewConditionRegistry(map[string]ConditionBuilder{
+		"tenant": func() Condition { return new(tenantCondition) },
+	})
+
+	p, err := NewPolicy(
+		PolicyID("tenant_docs"),
+		SetResources("/docs"),
+		SetActions("read"),
+		WithRole(NewRole("reader")),
+		PolicyAllow(),
+		WithCondition(ConditionOptions{
+			Name:    "tenant",
+			Type:    "tenant",
+			Options: map[string]interface{}{"tenant": "acme"},
+		}),
+		SetConditionRegistry(reg),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		reg     ConditionRegistry
+		wantErr bool
+	}{
+		{"registry", b, reg, false},
+		{"default_registry", b, nil, true},
+		{"no_conditions", []byte(`{"id":"plain","resources":["/docs"],"actions":["read"],"effect":"allow"}`), nil, false},
+		{"invalid_json", []byte(`{"id":`), reg, true},
+		{"shadow_mode", []byte(`{"id":"shadow","resources":["/docs"],"actions":["read"],"effect":"allow","mode":"shadow"}`), nil, false},
+		{"unknown_mode", []byte(`{"id":"typo","resources":["/docs"],"actions":["read"],"effect":"allow","mode":"shadw"}`), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalPolicy(tt.data, tt.reg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr || got.ID() != p.ID() {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Conditions(), p.Conditions()) {
+				t.Errorf("UnmarshalPolicy() conditions = %v, want %v", got.Conditions(), p.Conditions())
+			}
+
+			rb, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(rb) != string(b) {
+				t.Errorf("UnmarshalPolicy() round trip = %s, want %s", rb, b)
+			}
+		})
+	}
+}
+
+func TestNewPolicyMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		want    PolicyMode
+		wantErr bool
+	}{
+		{"default", "", PolicyModeEnforce, false},
+		{"enforce", "enforce", PolicyModeEnforce, false},
+		{"shadow", "shadow", PolicyModeShadow, false},
+		{"unknown", "shadw", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPolicyMode(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPolicyMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("NewPolicyMode() = %v, want %v", got, tt.want)
+			}
+
+			p, err := NewPolicy(PolicyID(tt.name), SetPolicyMode(tt.mode), PolicyDeny())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && p.Mode() != tt.want {
+				t.Errorf("NewPolicy() mode = %v, want %v", p.Mode(), tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,8 @@ package redtape
 var (
 	// DefaultMatcher is a simple matcher.
 	DefaultMatcher = NewMatcher()
-	// DefaultPolicyEffect is the policy effect to apply when no other matches can be found.
+	// DefaultPolicyEffect is the policy effect to apply when no other matches can be found. It is read when an
+	// Enforcer is constructed, use SetDefaultEffect to configure individual enforcers.
 	DefaultPolicyEffect = PolicyEffectDeny
 )
 
@@ -2,7 +2,10 @@ package redtape
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
@@ -163,3 +166,888 @@ func (s *RedtapeSuite) TestCEnforce() {
 	err = e.Enforce(req)
 	s.Require().Error(err, "should be denied")
 }
+
+func (s *RedtapeSuite) TestDDecide() {
+	pm := NewManager()
+
+	allow := MustNewPolicy(
+		PolicyID("allow_docs"),
+		SetResources("/docs/*"),
+		SetActions("read", "write"),
+		WithRole(NewRole("editor")),
+		PolicyAllow(),
+	)
+	deny := MustNewPolicy(
+		PolicyID("deny_write"),
+		SetResources("/docs/locked"),
+		SetActions("write"),
+		WithRole(NewRole("editor")),
+		PolicyDeny(),
+	)
+
+	for _, p := range []Policy{allow, deny} {
+		s.Require().NoError(pm.Create(p))
+	}
+
+	e, err := NewEnforcer(pm, NewMatcher(), nil)
+	s.Require().NoError(err)
+
+	d, err := e.Decide(NewRequest("/docs/readme", "read", "editor", ""))
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+	s.True(d.Explicit)
+	s.Equal([]string{"allow_docs"}, d.Matched)
+	s.Equal("allow_docs", d.Policy.ID())
+	s.NoError(d.Err())
+
+	d, err = e.Decide(NewRequest("/docs/locked", "write", "editor", ""))
+	s.Require().NoError(err)
+	s.Equal(PolicyEffectDeny, d.Effect)
+	s.True(d.Explicit)
+	s.ElementsMatch([]string{"allow_docs", "deny_write"}, d.Matched)
+	s.Equal("deny_write", d.Policy.ID())
+	s.Error(d.Err())
+
+	d, err = e.Decide(NewRequest("/docs/readme", "read", "viewer", ""))
+	s.Require().NoError(err)
+	s.Equal(PolicyEffectDeny, d.Effect)
+	s.False(d.Explicit)
+	s.Empty(d.Matched)
+	s.Nil(d.Policy)
+}
+
+func (s *RedtapeSuite) TestEEnforcerOptions() {
+	pm := NewManager()
+
+	_, err := NewEnforcerWithOptions(nil)
+	s.Error(err, "should require a policy manager")
+
+	_, err = NewEnforcerWithOptions(pm, SetMatcher(nil))
+	s.Error(err, "should require a matcher")
+
+	closed, err := NewEnforcerWithOptions(pm)
+	s.Require().NoError(err)
+
+	open, err := NewEnforcerWithOptions(pm,
+		SetMatcher(NewRegexMatcher()),
+		SetDefaultEffect(PolicyEffectAllow),
+	)
+	s.Require().NoError(err)
+
+	req := NewRequest("/unknown", "read", "nobody", "")
+
+	s.Error(closed.Enforce(req))
+	s.NoError(open.Enforce(req))
+}
+
+func (s *RedtapeSuite) TestFExplain() {
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_mfa"),
+		PolicyName("allow with mfa"),
+		SetResources("/account"),
+		SetActions("update"),
+		WithRole(NewRole("user")),
+		WithCondition(ConditionOptions{
+			Name: "mfa",
+			Type: "bool",
+			Options: map[string]interface{}{
+				"value": true,
+			},
+		}),
+		PolicyAllow(),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm, SetExplain(true))
+	s.Require().NoError(err)
+
+	d, err := e.Decide(NewRequest("/account", "update", "user", "", map[string]interface{}{
+		"mfa": false,
+	}))
+	s.Require().NoError(err)
+	s.False(d.Allowed())
+	s.Require().NotNil(d.Trace)
+	s.Require().Len(d.Trace.Policies, 1)
+
+	pt := d.Trace.Policies[0]
+	s.False(pt.Matched)
+	s.Require().Len(pt.Stages, 5)
+
+	last := pt.Stages[4]
+	s.Equal(TraceStageCondition, last.Stage)
+	s.Equal("mfa", last.Name)
+	s.Equal(false, last.Value)
+	s.False(last.Passed)
+
+	s.Contains(d.Trace.String(), "[fail] condition mfa: false against [bool]")
+
+	d, err = e.Decide(NewRequest("/account", "delete", "user", ""))
+	s.Require().NoError(err)
+	s.Len(d.Trace.Policies[0].Stages, 1)
+	s.Equal(TraceStageAction, d.Trace.Policies[0].Stages[0].Stage)
+}
+
+type countingManager struct {
+	PolicyManager
+	calls int
+}
+
+func (m *countingManager) All(limit, offset int) ([]Policy, error) {
+	m.calls++
+	return m.PolicyManager.All(limit, offset)
+}
+
+func (m *countingManager) FindByRequest(r *Request) ([]Policy, error) {
+	m.calls++
+	return m.PolicyManager.FindByRequest(r)
+}
+
+func (s *RedtapeSuite) TestGEnforceBatch() {
+	pm := &countingManager{PolicyManager: NewManager()}
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_public"),
+		SetResources("/public/*"),
+		SetActions("read"),
+		WithRole(NewRole("*")),
+		PolicyAllow(),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm, SetBatchConcurrency(4))
+	s.Require().NoError(err)
+
+	reqs := make([]*Request, 0, 50)
+	for i := 0; i < 50; i++ {
+		res := "/public/" + uuid.NewString()
+		if i%2 == 1 {
+			res = "/private/" + uuid.NewString()
+		}
+
+		reqs = append(reqs, NewRequest(res, "read", "*", ""))
+	}
+
+	ds, err := e.EnforceBatch(context.Background(), reqs)
+	s.Require().NoError(err)
+	s.Require().Len(ds, len(reqs))
+	s.Equal(1, pm.calls, "policies should be loaded once")
+
+	for i, d := range ds {
+		s.Equal(i%2 == 0, d.Allowed(), reqs[i].Resource)
+	}
+
+	ds, err = e.EnforceBatch(context.Background(), nil)
+	s.Require().NoError(err)
+	s.Empty(ds)
+}
+
+func (s *RedtapeSuite) TestHContextCancel() {
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_all"),
+		SetResources("*"),
+		SetActions("*"),
+		WithRole(NewRole("user")),
+		PolicyAllow(),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm)
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := NewRequestWithContext(ctx, "/docs", "read", "user", "")
+
+	d, err := e.Decide(req)
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+
+	cancel()
+
+	_, err = e.Decide(req)
+	s.ErrorIs(err, context.Canceled)
+	s.ErrorIs(e.Enforce(req), context.Canceled)
+
+	_, err = e.EnforceBatch(ctx, []*Request{NewRequest("/docs", "read", "user", "")})
+	s.ErrorIs(err, context.Canceled)
+
+	cm, ok := pm.(ContextPolicyManager)
+	s.Require().True(ok)
+
+	_, err = cm.GetContext(ctx, "allow_all")
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *RedtapeSuite) TestICache() {
+	mgr := NewManager()
+	pm := &countingManager{PolicyManager: mgr}
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_docs"),
+		SetResources("/docs/*"),
+		SetActions("read"),
+		WithRole(NewRole("user")),
+		PolicyAllow(),
+	)))
+
+	notifying := struct {
+		*countingManager
+		PolicyNotifier
+	}{pm, mgr.(PolicyNotifier)}
+
+	_, err := NewEnforcerWithOptions(pm, SetDecisionCache(CacheOptions{}))
+	s.Error(err)
+
+	e, err := NewEnforcerWithOptions(notifying, SetDecisionCache(CacheOptions{
+		MetadataKeys: []string{"tenant"},
+	}))
+	s.Require().NoError(err)
+
+	req := NewRequest("/docs/1", "read", "user", "", map[string]interface{}{"tenant": "a"})
+
+	for i := 0; i < 3; i++ {
+		s.NoError(e.Enforce(req))
+	}
+
+	s.Equal(1, pm.calls)
+
+	other := NewRequest("/docs/1", "read", "user", "", map[string]interface{}{"tenant": "b"})
+	s.NoError(e.Enforce(other))
+	s.Equal(2, pm.calls)
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("deny_docs"),
+		SetResources("/docs/*"),
+		SetActions("read"),
+		WithRole(NewRole("user")),
+		PolicyDeny(),
+	)))
+
+	s.Error(e.Enforce(req))
+	s.Equal(3, pm.calls)
+
+	// decisions depending on condition inputs outside the cache key are not cached
+	cmgr := NewManager()
+	cpm := &countingManager{PolicyManager: cmgr}
+
+	s.Require().NoError(cpm.Create(MustNewPolicy(
+		PolicyID("allow_mfa"),
+		SetResources("/admin"),
+		SetActions("read"),
+		WithRole(NewRole("user")),
+		PolicyAllow(),
+		WithCondition(ConditionOptions{Name: "mfa", Type: "bool", Options: map[string]interface{}{"value": true}}),
+	)))
+
+	cnotifying := struct {
+		*countingManager
+		PolicyNotifier
+	}{cpm, cmgr.(PolicyNotifier)}
+
+	mfa := NewRequest("/admin", "read", "user", "", map[string]interface{}{"mfa": true})
+	noMFA := NewRequest("/admin", "read", "user", "", map[string]interface{}{"mfa": false})
+
+	e, err = NewEnforcerWithOptions(cnotifying, SetDecisionCache(CacheOptions{MetadataKeys: []string{"tenant"}}))
+	s.Require().NoError(err)
+
+	s.NoError(e.Enforce(mfa))
+	s.Error(e.Enforce(noMFA))
+	s.NoError(e.Enforce(mfa))
+	s.Equal(3, cpm.calls)
+
+	// conditions keyed by their metadata value are cached per value
+	cpm.calls = 0
+
+	e, err = NewEnforcerWithOptions(cnotifying, SetDecisionCache(CacheOptions{MetadataKeys: []string{"mfa"}}))
+	s.Require().NoError(err)
+
+	s.NoError(e.Enforce(mfa))
+	s.Error(e.Enforce(noMFA))
+	s.NoError(e.Enforce(mfa))
+	s.Error(e.Enforce(noMFA))
+	s.Equal(2, cpm.calls)
+
+	// role manager changes invalidate the cache
+	rm := NewRoleManager()
+	s.Require().NoError(rm.Create(NewRole("user", NewRole("viewer"))))
+
+	_, err = NewEnforcerWithOptions(notifying, SetRoleManager(struct{ RoleManager }{rm}), SetDecisionCache(CacheOptions{}))
+	s.Error(err)
+
+	e, err = NewEnforcerWithOptions(notifying, SetRoleManager(rm), SetDecisionCache(CacheOptions{}))
+	s.Require().NoError(err)
+
+	viewer := NewRequest("/docs/1", "write", "viewer", "")
+
+	s.Require().NoError(pm.Delete("deny_docs"))
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("write_docs"),
+		SetResources("/docs/*"),
+		SetActions("write"),
+		WithRole(NewRole("user")),
+		PolicyAllow(),
+	)))
+
+	pm.calls = 0
+
+	s.NoError(e.Enforce(viewer))
+	s.NoError(e.Enforce(viewer))
+	s.Equal(1, pm.calls)
+
+	s.Require().NoError(rm.Update(NewRole("user")))
+
+	s.Error(e.Enforce(viewer))
+	s.Equal(2, pm.calls)
+}
+
+type shadowRecorder struct {
+	effects []PolicyEffect
+	shadows []*ShadowDecision
+}
+
+func (a *shadowRecorder) LogRequest(*Request) {}
+
+func (a *shadowRecorder) LogPolicyEffect(_ *Request, effect PolicyEffect) {
+	a.effects = append(a.effects, effect)
+}
+
+func (a *shadowRecorder) LogShadowEffect(_ *Request, _ PolicyEffect, shadow *ShadowDecision) {
+	a.shadows = append(a.shadows, shadow)
+}
+
+func (s *RedtapeSuite) TestJShadow() {
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_docs"),
+		SetResources("/docs/*"),
+		SetActions("*"),
+		WithRole(NewRole("user")),
+		PolicyAllow(),
+	)))
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("deny_delete"),
+		SetResources("/docs/*"),
+		SetActions("delete"),
+		WithRole(NewRole("user")),
+		PolicyDeny(),
+		PolicyShadow(),
+	)))
+
+	a := &shadowRecorder{}
+
+	e, err := NewEnforcerWithOptions(pm, SetAuditor(a))
+	s.Require().NoError(err)
+
+	d, err := e.Decide(NewRequest("/docs/1", "read", "user", ""))
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+	s.Nil(d.Shadow)
+
+	d, err = e.Decide(NewRequest("/docs/1", "delete", "user", ""))
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+	s.Equal([]string{"allow_docs"}, d.Matched)
+	s.Require().NotNil(d.Shadow)
+	s.Equal(PolicyEffectDeny, d.Shadow.Effect)
+	s.Equal([]string{"deny_delete"}, d.Shadow.Matched)
+	s.True(d.Shadow.Diverges)
+
+	s.Equal([]PolicyEffect{PolicyEffectAllow, PolicyEffectAllow}, a.effects)
+	s.Require().Len(a.shadows, 1)
+	s.True(a.shadows[0].Diverges)
+
+	grants, err := RoleGrants(pm, NewMatcher(), NewRole("user"))
+	s.Require().NoError(err)
+
+	for _, g := range grants {
+		s.NotEqual("deny_delete", g.PolicyID)
+	}
+}
+
+func (s *RedtapeSuite) TestKObligations() {
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("allow_records"),
+		SetResources("/records/*"),
+		SetActions("read"),
+		WithRole(NewRole("clerk")),
+		PolicyAllow(),
+		WithObligation(NewObligation("log", map[string]interface{}{"reason": "records access"})),
+		WithAdvice(NewObligation("mask", map[string]interface{}{"field": "ssn"})),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm)
+	s.Require().NoError(err)
+
+	req := NewRequest("/records/1", "read", "clerk", "")
+
+	d, err := e.Decide(req)
+	s.Require().NoError(err)
+	s.False(d.Allowed())
+	s.Len(d.Unfulfilled, 1)
+	s.Error(e.Enforce(req))
+
+	var logged []Obligation
+
+	e, err = NewEnforcerWithOptions(pm, WithObligationHandler("log", func(_ *Request, o Obligation) error {
+		logged = append(logged, o)
+		return nil
+	}))
+	s.Require().NoError(err)
+
+	d, err = e.Decide(req)
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+	s.Empty(d.Unfulfilled)
+	s.Equal("ssn", d.Advice[0].Values["field"])
+	s.Require().Len(logged, 1)
+	s.Equal("records access", logged[0].Values["reason"])
+
+	e, err = NewEnforcerWithOptions(pm, WithObligationHandler("log", func(*Request, Obligation) error {
+		return errors.New("audit log unavailable")
+	}))
+	s.Require().NoError(err)
+
+	s.Error(e.Enforce(req))
+}
+
+func (s *RedtapeSuite) TestLPriorityOrder() {
+	pm := NewManager()
+
+	for i, id := range []string{"c", "a", "d", "b"} {
+		s.Require().NoError(pm.Create(MustNewPolicy(
+			PolicyID(id),
+			SetResources("*"),
+			SetActions("*"),
+			WithRole(NewRole("user")),
+			PolicyAllow(),
+			SetPriority(i%2),
+		)))
+	}
+
+	all, err := pm.All(10, 0)
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b", "c", "d"}, policyIDs(all))
+
+	page, err := pm.All(2, 1)
+	s.Require().NoError(err)
+	s.Equal([]string{"b", "c"}, policyIDs(page))
+
+	e, err := NewEnforcerWithOptions(pm, SetExplain(true))
+	s.Require().NoError(err)
+
+	d, err := e.Decide(NewRequest("/docs", "read", "user", ""))
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b", "c", "d"}, d.Matched)
+
+	for i, pt := range d.Trace.Policies {
+		s.Equal(d.Matched[i], pt.PolicyID)
+	}
+}
+
+func (s *RedtapeSuite) TestMValidity() {
+	pm := NewManager()
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("incident_access"),
+		SetResources("/prod/*"),
+		SetActions("*"),
+		WithRole(NewRole("oncall")),
+		PolicyAllow(),
+		SetNotBefore(now.Add(-time.Hour)),
+		SetNotAfter(now.Add(time.Hour)),
+	)))
+
+	_, err := NewPolicy(WithSchedule(Schedule{Start: "25:00", End: "17:00"}))
+	s.Error(err)
+
+	clock := now
+	e, err := NewEnforcerWithOptions(pm,
+		SetExplain(true),
+		SetClock(func() time.Time { return clock }),
+		SetDecisionCache(CacheOptions{}),
+	)
+	s.Require().NoError(err)
+
+	req := NewRequest("/prod/db", "ssh", "oncall", "")
+
+	d, err := e.Decide(req)
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+
+	clock = now.Add(2 * time.Hour)
+
+	d, err = e.Decide(req)
+	s.Require().NoError(err)
+	s.False(d.Allowed())
+	s.Require().Len(d.Trace.Policies, 1)
+	s.Equal(TraceStageValidity, d.Trace.Policies[0].Stages[0].Stage)
+	s.False(d.Trace.Policies[0].Stages[0].Passed)
+
+	ef, ok := pm.(ExpiredPolicyFinder)
+	s.Require().True(ok)
+
+	expired, err := ef.FindExpired(now)
+	s.Require().NoError(err)
+	s.Empty(expired)
+
+	expired, err = ef.FindExpired(clock)
+	s.Require().NoError(err)
+	s.Equal([]string{"incident_access"}, policyIDs(expired))
+}
+
+func (s *RedtapeSuite) TestNTemplates() {
+	_, err := NewPolicy(SetResources("/users/${user.id}"))
+	s.Error(err)
+
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("edit_own_profile"),
+		SetResources("/users/${subject.id}/*"),
+		SetActions("edit"),
+		SetScopes("tenant:${meta.tenant}"),
+		WithRole(NewRole("alice")),
+		WithRole(NewRole("bob")),
+		PolicyAllow(),
+	)))
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("read_own_orders"),
+		SetResources("/orders/<[0-9]+>/${subject.id}"),
+		SetActions("read"),
+		SetScopes("*"),
+		WithRole(NewRole("alice")),
+		WithRole(NewRole("bob")),
+		PolicyAllow(),
+	)))
+
+	meta := map[string]interface{}{"tenant": "acme"}
+
+	for _, m := range []Matcher{NewMatcher(), NewRegexMatcher()} {
+		e, err := NewEnforcerWithOptions(pm, SetMatcher(m))
+		s.Require().NoError(err)
+
+		s.NoError(e.Enforce(NewRequest("/users/alice/profile", "edit", "alice", "tenant:acme", meta)))
+		s.Error(e.Enforce(NewRequest("/users/bob/profile", "edit", "alice", "tenant:acme", meta)))
+		s.Error(e.Enforce(NewRequest("/users/alice/profile", "edit", "alice", "tenant:other", meta)))
+		s.Error(e.Enforce(NewRequest("/users/alice/profile", "edit", "alice", "tenant:acme")))
+		s.NoError(e.Enforce(NewRequest("/users/bob/profile", "edit", "bob", "tenant:acme", meta)))
+	}
+
+	e, err := NewEnforcerWithOptions(pm, SetMatcher(NewRegexMatcher()))
+	s.Require().NoError(err)
+
+	s.NoError(e.Enforce(NewRequest("/orders/42/alice", "read", "alice", "")))
+	s.Error(e.Enforce(NewRequest("/orders/42/bob", "read", "alice", "")))
+	s.Error(e.Enforce(NewRequest("/orders/x/alice", "read", "alice", "")))
+
+	f, err := PartialEval(pm, NewMatcher(), NewRequest("", "edit", "alice", "tenant:acme", meta))
+	s.Require().NoError(err)
+	s.Require().Len(f.Allow, 1)
+	s.Equal("/users/alice/*", f.Allow[0].Pattern)
+}
+
+func (s *RedtapeSuite) TestOSubject() {
+	viewer := NewRole("viewer")
+	editor := NewRole("editor")
+	s.Require().NoError(editor.AddRole(viewer))
+
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("view_docs"),
+		SetResources("/docs/*"),
+		SetActions("read"),
+		WithRole(viewer),
+		PolicyAllow(),
+	)))
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("billing_export"),
+		SetResources("/billing/export"),
+		SetActions("read"),
+		WithRole(NewRole("billing")),
+		PolicyAllow(),
+	)))
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("owner_delete"),
+		SetResources("/docs/*"),
+		SetActions("delete"),
+		WithSubject("alice"),
+		PolicyAllow(),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm, SetExplain(true))
+	s.Require().NoError(err)
+
+	alice := NewSubject("alice", editor, NewRole("billing"))
+	bob := NewSubject("bob", editor)
+
+	s.NoError(e.Enforce(NewSubjectRequest("/docs/1", "read", alice, "")))
+	s.NoError(e.Enforce(NewSubjectRequest("/billing/export", "read", alice, "")))
+	s.NoError(e.Enforce(NewSubjectRequest("/docs/1", "delete", alice, "")))
+
+	s.NoError(e.Enforce(NewSubjectRequest("/docs/1", "read", bob, "")))
+	s.Error(e.Enforce(NewSubjectRequest("/billing/export", "read", bob, "")))
+
+	d, err := e.Decide(NewSubjectRequest("/docs/1", "delete", bob, ""))
+	s.Require().NoError(err)
+	s.False(d.Allowed())
+
+	for _, pt := range d.Trace.Policies {
+		if pt.PolicyID != "owner_delete" {
+			continue
+		}
+
+		last := pt.Stages[len(pt.Stages)-1]
+		s.Equal(TraceStageSubject, last.Stage)
+		s.Equal("bob", last.Value)
+	}
+
+	s.NoError(e.Enforce(NewRequest("/docs/1", "read", "viewer", "")))
+}
+
+func (s *RedtapeSuite) TestPRoleBindings() {
+	viewer := NewRole("viewer")
+	editor := NewRole("editor", viewer)
+
+	rm := NewRoleManager()
+	s.Require().NoError(rm.Create(editor))
+
+	pm := NewManager()
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("view_docs"),
+		SetResources("/docs/*"),
+		SetActions("read"),
+		WithRole(viewer),
+		PolicyAllow(),
+	)))
+
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("page_oncall"),
+		SetResources("/pager"),
+		SetActions("ack"),
+		WithRole(NewRole("oncall")),
+		PolicyAllow(),
+	)))
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	shiftEnd := now.Add(8 * time.Hour)
+
+	bm := NewRoleBindingManager()
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "editor", nil)))
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "oncall", &shiftEnd)))
+	s.Require().NoError(bm.Bind(NewRoleBinding("bob", "oncall", nil)))
+
+	subjects, err := bm.SubjectsFor("oncall", now)
+	s.Require().NoError(err)
+	s.Len(subjects, 2)
+
+	clock := now
+	e, err := NewEnforcerWithOptions(pm,
+		SetRoleBindingManager(bm),
+		SetRoleManager(rm),
+		SetClock(func() time.Time { return clock }),
+		SetDecisionCache(CacheOptions{}),
+	)
+	s.Require().NoError(err)
+
+	alice := NewSubject("alice")
+
+	s.NoError(e.Enforce(NewSubjectRequest("/docs/1", "read", alice, "")))
+	s.NoError(e.Enforce(NewSubjectRequest("/pager", "ack", alice, "")))
+	s.Error(e.Enforce(NewSubjectRequest("/docs/1", "read", NewSubject("bob"), "")))
+
+	clock = shiftEnd
+	s.Error(e.Enforce(NewSubjectRequest("/pager", "ack", alice, "")))
+
+	s.Require().NoError(bm.Unbind("alice", "editor"))
+	s.Error(e.Enforce(NewSubjectRequest("/docs/1", "read", alice, "")))
+	s.Error(bm.Unbind("alice", "editor"))
+}
+
+func (s *RedtapeSuite) TestQRoleReferences() {
+	rm := NewRoleManager()
+	s.Require().NoError(rm.Create(NewRole("editor", NewRole("viewer"))))
+
+	var opts PolicyOptions
+	s.Require().NoError(json.Unmarshal([]byte(`{
+		"id": "edit_docs",
+		"roles": ["editor"],
+		"resources": ["/docs"],
+		"actions": ["read"],
+		"effect": "allow"
+	}`), &opts))
+
+	pm := NewManager()
+	s.Require().NoError(pm.Create(MustNewPolicy(SetPolicyOptions(opts))))
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("ghost_docs"),
+		SetResources("/docs"),
+		SetActions("write"),
+		WithRoleID("ghost"),
+		PolicyAllow(),
+	)))
+
+	stale, err := NewEnforcerWithOptions(pm)
+	s.Require().NoError(err)
+	s.Error(stale.Enforce(NewRequest("/docs", "read", "viewer", "")))
+
+	e, err := NewEnforcerWithOptions(pm, SetRoleManager(rm))
+	s.Require().NoError(err)
+	s.NoError(e.Enforce(NewRequest("/docs", "read", "viewer", "")))
+
+	cached, err := NewEnforcerWithOptions(pm, SetRoleManager(rm), SetRoleCache(time.Hour))
+	s.Require().NoError(err)
+	s.NoError(cached.Enforce(NewRequest("/docs", "read", "viewer", "")))
+
+	s.Require().NoError(rm.Update(NewRole("editor", NewRole("guest"))))
+
+	s.Error(e.Enforce(NewRequest("/docs", "read", "viewer", "")))
+	s.NoError(e.Enforce(NewRequest("/docs", "read", "guest", "")))
+	s.Error(cached.Enforce(NewRequest("/docs", "read", "viewer", "")))
+	s.NoError(cached.Enforce(NewRequest("/docs", "read", "guest", "")))
+
+	d, err := e.Decide(NewRequest("/docs", "write", "ghost", ""))
+	s.Require().NoError(err)
+	s.True(d.Allowed())
+	s.Equal([]DanglingRole{{PolicyID: "ghost_docs", RoleID: "ghost"}}, d.DanglingRoles)
+
+	dangling, err := FindDanglingRoles(pm, rm)
+	s.Require().NoError(err)
+	s.Equal([]DanglingRole{{PolicyID: "ghost_docs", RoleID: "ghost"}}, dangling)
+}
+
+func (s *RedtapeSuite) TestQRoleReferencesNested() {
+	rm := NewRoleManager()
+	s.Require().NoError(rm.Create(NewRole("viewer")))
+	s.Require().NoError(rm.Create(NewRole("editor", NewRole("viewer"))))
+	s.Require().NoError(rm.Create(NewRole("admin", NewRole("editor"))))
+
+	pm := NewManager()
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("admin_docs"),
+		SetResources("/docs"),
+		SetActions("read"),
+		WithRoleID("admin"),
+		PolicyAllow(),
+	)))
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("view_reports"),
+		SetResources("/reports"),
+		SetActions("read"),
+		WithRoleID("viewer"),
+		PolicyAllow(),
+	)))
+
+	bm := NewRoleBindingManager()
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "admin", nil)))
+
+	e, err := NewEnforcerWithOptions(pm, SetRoleManager(rm), SetRoleBindingManager(bm))
+	s.Require().NoError(err)
+
+	s.NoError(e.Enforce(NewRequest("/docs", "read", "viewer", "")))
+	s.NoError(e.Enforce(NewRequest("/docs", "read", "editor", "")))
+	s.Error(e.Enforce(NewRequest("/docs", "read", "guest", "")))
+	s.NoError(e.Enforce(NewSubjectRequest("/reports", "read", NewSubject("alice"), "")))
+
+	s.Require().NoError(rm.Update(NewRole("editor", NewRole("guest"))))
+
+	s.Error(e.Enforce(NewRequest("/docs", "read", "viewer", "")))
+	s.NoError(e.Enforce(NewRequest("/docs", "read", "guest", "")))
+	s.Error(e.Enforce(NewSubjectRequest("/reports", "read", NewSubject("alice"), "")))
+}
+
+type sodRecorder struct {
+	shadowRecorder
+	violations []*SoDViolation
+}
+
+func (a *sodRecorder) LogSoDViolation(_ *Request, v *SoDViolation) {
+	a.violations = append(a.violations, v)
+}
+
+func (s *RedtapeSuite) TestRSeparationOfDuty() {
+	cs := SoDConstraints{
+		NewStaticSoD("payments", "payments_submit", "payments_approve"),
+		NewDynamicSoD("deploy", "deploy_write", "deploy_review"),
+	}
+
+	audit := &sodRecorder{}
+
+	rm := NewSoDRoleManager(NewRoleManager(), cs, audit)
+	s.Require().NoError(rm.Create(NewRole("payments_approve")))
+	s.Require().NoError(rm.Create(NewRole("clerk", NewRole("payments_submit"))))
+
+	var v *SoDViolation
+	s.Require().ErrorAs(rm.Create(NewRole("finance", NewRole("clerk"), NewRole("payments_approve"))), &v)
+	s.Equal("payments", v.Constraint.ID)
+
+	s.Require().NoError(rm.Create(NewRole("manager", NewRole("clerk"))))
+	s.ErrorAs(rm.Update(NewRole("clerk", NewRole("payments_submit"), NewRole("payments_approve"))), &v)
+	s.ErrorAs(rm.Update(NewRole("payments_approve", NewRole("payments_submit"))), &v)
+	s.Len(audit.violations, 3)
+
+	bm := NewSoDRoleBindingManager(NewRoleBindingManager(), rm, cs, audit)
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "clerk", nil)))
+	s.ErrorAs(bm.Bind(NewRoleBinding("alice", "payments_approve", nil)), &v)
+	s.Equal("alice", v.Subject)
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "deploy_write", nil)))
+	s.Require().NoError(bm.Bind(NewRoleBinding("alice", "deploy_review", nil)))
+	s.Len(audit.violations, 4)
+
+	pm := NewManager()
+	s.Require().NoError(pm.Create(MustNewPolicy(
+		PolicyID("deploy"),
+		SetResources("/deploys/*"),
+		SetActions("*"),
+		WithRole(NewRole("deploy_write")),
+		WithRole(NewRole("deploy_review")),
+		PolicyAllow(),
+	)))
+
+	e, err := NewEnforcerWithOptions(pm, SetSoDConstraints(cs...), SetAuditor(audit))
+	s.Require().NoError(err)
+
+	s.NoError(e.Enforce(NewSubjectRequest("/deploys/1", "merge", NewSubject("alice", NewRole("deploy_write")), "")))
+
+	d, err := e.Decide(NewSubjectRequest("/deploys/1", "merge", NewSubject("alice", NewRole("deploy_write"), NewRole("deploy_review")), ""))
+	s.Require().NoError(err)
+	s.False(d.Allowed())
+	s.Require().NotNil(d.Violation)
+	s.Equal("deploy", d.Violation.Constraint.ID)
+	s.ErrorAs(d.Err(), &v)
+	s.Len(audit.violations, 5)
+
+	plain := NewRoleBindingManager()
+	s.Require().NoError(plain.Bind(NewRoleBinding("bob", "payments_submit", nil)))
+	s.Require().NoError(plain.Bind(NewRoleBinding("bob", "payments_approve", nil)))
+
+	bound, err := NewEnforcerWithOptions(pm,
+		SetSoDConstraints(cs...),
+		SetRoleBindingManager(bm),
+		SetAuditor(audit),
+	)
+	s.Require().NoError(err)
+
+	s.NoError(bound.Enforce(NewSubjectRequest("/deploys/1", "merge", NewSubject("alice", NewRole("deploy_write")), "")))
+	s.NoError(bound.Enforce(NewSubjectRequest("/deploys/1", "merge", NewSubject("alice"), "")))
+	req := NewSubjectRequest("/deploys/1", "merge", NewSubject("alice", NewRole("deploy_review")), "")
+	req.Role = "deploy_write"
+	s.ErrorAs(bound.Enforce(req), &v)
+	s.Equal("deploy", v.Constraint.ID)
+
+	held, err := NewEnforcerWithOptions(pm, SetSoDConstraints(cs...), SetRoleBindingManager(plain))
+	s.Require().NoError(err)
+
+	s.ErrorAs(held.Enforce(NewSubjectRequest("/deploys/1", "merge", NewSubject("bob", NewRole("deploy_write")), "")), &v)
+	s.Equal("payments", v.Constraint.ID)
+}
@@ -2,11 +2,14 @@ package redtape
 
 import "context"
 
-// Request represents a request to be matched against a policy set.
+// Request represents a request to be matched against a policy set. Role holds a single role ID while
+// Subject describes the requesting principal with its ID, roles and attributes. Policies match when either
+// Role or any of the effective roles of Subject matches.
 type Request struct {
 	Resource string          `json:"resource"`
 	Action   string          `json:"action"`
 	Role     string          `json:"subject"`
+	Subject  *Subject        `json:"principal,omitempty"`
 	Scope    string          `json:"scope"`
 	Context  context.Context `json:"-"`
 }
@@ -32,11 +35,70 @@ func NewRequestWithContext(ctx context.Context, res, action, role, scope string,
 	}
 }
 
+// NewSubjectRequest builds a request made by a Subject.
+func NewSubjectRequest(res, action string, sub *Subject, scope string, meta ...map[string]interface{}) *Request {
+	return NewSubjectRequestWithContext(context.Background(), res, action, sub, scope, meta...)
+}
+
+// NewSubjectRequestWithContext builds a request made by a Subject from the provided parameters.
+func NewSubjectRequestWithContext(
+	ctx context.Context,
+	res, action string,
+	sub *Subject,
+	scope string,
+	meta ...map[string]interface{},
+) *Request {
+	return &Request{
+		Resource: res,
+		Action:   action,
+		Subject:  sub,
+		Scope:    scope,
+		Context:  NewRequestContext(ctx, meta...),
+	}
+}
+
+// SubjectID returns the ID of the request Subject, falling back to Role when no Subject is set.
+func (r *Request) SubjectID() string {
+	if r.Subject != nil {
+		return r.Subject.ID
+	}
+
+	return r.Role
+}
+
+// Roles returns the request Role followed by the effective roles of the Subject. The request Role is
+// returned on its own when the request holds no other roles.
+func (r *Request) Roles() ([]string, error) {
+	if r.Subject == nil {
+		return []string{r.Role}, nil
+	}
+
+	er, err := r.Subject.EffectiveRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Role == "" && len(er) > 0 {
+		return er, nil
+	}
+
+	return append([]string{r.Role}, er...), nil
+}
+
 // Metadata returns metadata stored in context or an empty set.
 func (r *Request) Metadata() RequestMetadata {
 	return RequestMetadataFromContext(r.Context)
 }
 
+// ctx returns the request context or a background context when none is set.
+func (r *Request) ctx() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+
+	return r.Context
+}
+
 // RequestMetadata is a helper type to allow type safe retrieval.
 type RequestMetadata map[string]interface{}
 
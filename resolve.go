@@ -0,0 +1,216 @@
+package redtape
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DanglingRole is a role referenced by a policy that does not exist in the RoleManager.
+type DanglingRole struct {
+	PolicyID string `json:"policy_id"`
+	RoleID   string `json:"role_id"`
+}
+
+// FindDanglingRoles returns the role references of every policy stored in pm that cannot be resolved
+// through rm.
+func FindDanglingRoles(pm PolicyManager, rm RoleManager) ([]DanglingRole, error) {
+	return FindDanglingRolesContext(context.Background(), pm, rm)
+}
+
+// FindDanglingRolesContext returns the role references of every policy stored in pm that cannot be resolved
+// through rm unless ctx is done.
+func FindDanglingRolesContext(ctx context.Context, pm PolicyManager, rm RoleManager) ([]DanglingRole, error) {
+	pol, err := allPolicies(ctx, pm)
+	if err != nil {
+		return nil, err
+	}
+
+	rr := newRoleResolver(rm, 0)
+
+	pol, err = rr.resolvePolicies(ctx, ordered(pol))
+	if err != nil {
+		return nil, err
+	}
+
+	dangling := []DanglingRole{}
+	for _, p := range pol {
+		dangling = append(dangling, danglingRoles(p)...)
+	}
+
+	return dangling, nil
+}
+
+// resolvedPolicy is a Policy whose roles were replaced by their current definition in a RoleManager.
+type resolvedPolicy struct {
+	Policy
+	roles    []*Role
+	dangling []string
+}
+
+// Roles returns the resolved roles of the policy.
+func (p *resolvedPolicy) Roles() []*Role {
+	return p.roles
+}
+
+func danglingRoles(p Policy) []DanglingRole {
+	rp, ok := p.(*resolvedPolicy)
+	if !ok {
+		return nil
+	}
+
+	refs := make([]DanglingRole, 0, len(rp.dangling))
+	for _, id := range rp.dangling {
+		refs = append(refs, DanglingRole{PolicyID: p.ID(), RoleID: id})
+	}
+
+	return refs
+}
+
+type resolvedRole struct {
+	role    *Role
+	expires time.Time
+}
+
+// roleResolver looks up policy roles by ID in a RoleManager, caching the results for ttl when it is set.
+type roleResolver struct {
+	rm      RoleManager
+	ttl     time.Duration
+	entries map[string]resolvedRole
+	mu      sync.Mutex
+}
+
+func newRoleResolver(rm RoleManager, ttl time.Duration) *roleResolver {
+	return &roleResolver{
+		rm:      rm,
+		ttl:     ttl,
+		entries: make(map[string]resolvedRole),
+	}
+}
+
+// resolve returns the role with id, or nil when the RoleManager does not hold it.
+func (rr *roleResolver) resolve(ctx context.Context, id string) (*Role, error) {
+	if rr.ttl > 0 {
+		rr.mu.Lock()
+		ent, ok := rr.entries[id]
+		rr.mu.Unlock()
+
+		if ok && time.Now().Before(ent.expires) {
+			return ent.role, nil
+		}
+	}
+
+	role, err := getRole(ctx, rr.rm, id)
+	if err != nil {
+		if !errors.Is(err, ErrRoleNotFound) {
+			return nil, err
+		}
+
+		role = nil
+	}
+
+	if rr.ttl > 0 {
+		rr.mu.Lock()
+		rr.entries[id] = resolvedRole{role: role, expires: time.Now().Add(rr.ttl)}
+		rr.mu.Unlock()
+	}
+
+	return role, nil
+}
+
+// invalidate drops every cached role after a role change.
+func (rr *roleResolver) invalidate(RoleChange, string) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.entries = make(map[string]resolvedRole)
+}
+
+// expandedRole is a role expanded through the RoleManager and whether the manager holds it.
+type expandedRole struct {
+	role  *Role
+	found bool
+}
+
+// isRolePattern evaluates true for wildcard and regex role patterns, which are not role references.
+func isRolePattern(id string) bool {
+	return strings.ContainsAny(id, "*?<>")
+}
+
+// expand returns r replaced by its definition in the RoleManager, with every sub role expanded the same way
+// down the whole hierarchy, and whether the RoleManager holds r. Roles that are not found keep their embedded
+// definition. expanded holds the roles expanded so far so shared sub roles are only looked up once.
+func (rr *roleResolver) expand(ctx context.Context, r *Role, expanded map[string]expandedRole) (*Role, bool, error) {
+	if e, ok := expanded[r.ID]; ok {
+		return e.role, e.found, nil
+	}
+
+	def, err := rr.resolve(ctx, r.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	found := def != nil
+	if !found {
+		def = r
+	}
+
+	// recorded before descending, a cycle between stored roles ends here and is reported by EffectiveRoles
+	role := &Role{ID: def.ID, Name: def.Name, Description: def.Description}
+	expanded[r.ID] = expandedRole{role: role, found: found}
+
+	for _, sr := range def.Roles {
+		if isRolePattern(sr.ID) {
+			role.Roles = append(role.Roles, sr)
+			continue
+		}
+
+		sub, _, err := rr.expand(ctx, sr, expanded)
+		if err != nil {
+			return nil, false, err
+		}
+
+		role.Roles = append(role.Roles, sub)
+	}
+
+	return role, found, nil
+}
+
+// resolvePolicies returns pol with the roles of each policy, and transitively their sub roles, replaced by
+// their definition in the RoleManager. Policy roles that are not found keep their embedded definition and
+// are recorded as dangling. Wildcard and regex role patterns are not references and are left unchanged.
+func (rr *roleResolver) resolvePolicies(ctx context.Context, pol []Policy) ([]Policy, error) {
+	res := make([]Policy, 0, len(pol))
+	expanded := make(map[string]expandedRole)
+
+	for _, p := range pol {
+		rp := &resolvedPolicy{
+			Policy: p,
+			roles:  make([]*Role, 0, len(p.Roles())),
+		}
+
+		for _, r := range p.Roles() {
+			if isRolePattern(r.ID) {
+				rp.roles = append(rp.roles, r)
+				continue
+			}
+
+			role, found, err := rr.expand(ctx, r, expanded)
+			if err != nil {
+				return nil, err
+			}
+
+			if !found {
+				rp.dangling = append(rp.dangling, r.ID)
+			}
+
+			rp.roles = append(rp.roles, role)
+		}
+
+		res = append(res, rp)
+	}
+
+	return res, nil
+}
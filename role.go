@@ -1,14 +1,10 @@
 package redtape
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 )
 
-const (
-	maxIterDepth = 10
-)
-
 // Role represents a named association to a set of permissionable capability.
 type Role struct {
 	ID          string  `json:"id"`
@@ -25,7 +21,28 @@ func NewRole(id string, roles ...*Role) *Role {
 	}
 }
 
-// AddRole adds a subrole.
+// UnmarshalJSON decodes a Role from its object form or from a plain string holding a role ID, allowing
+// policies to reference roles by ID only.
+func (r *Role) UnmarshalJSON(b []byte) error {
+	var id string
+	if err := json.Unmarshal(b, &id); err == nil {
+		*r = Role{ID: id}
+		return nil
+	}
+
+	type role Role
+
+	var rr role
+	if err := json.Unmarshal(b, &rr); err != nil {
+		return err
+	}
+
+	*r = Role(rr)
+
+	return nil
+}
+
+// AddRole adds a subrole. An error is returned when role already inherits from r.
 func (r *Role) AddRole(role *Role) error {
 	if r.ID == role.ID {
 		return fmt.Errorf("sub role id %s cannot match parent", role.ID)
@@ -36,33 +53,66 @@ func (r *Role) AddRole(role *Role) error {
 		}
 	}
 
-	r.Roles = append(r.Roles, role)
+	roles := append(append([]*Role{}, r.Roles...), role)
+	if _, err := (&Role{ID: r.ID, Roles: roles}).EffectiveRoles(); err != nil {
+		return err
+	}
+
+	r.Roles = roles
 
 	return nil
 }
 
-func getEffectiveRoles(r *Role, iter int) ([]*Role, error) {
-	if iter > maxIterDepth {
-		return nil, errors.New("maximum recursion reached")
-	}
-
+// EffectiveRoles returns a flattened slice of the Role and all of its sub roles in depth first order. Roles
+// reachable through several paths are only included and walked once and an error wrapping ErrRoleCycle is
+// returned when a role inherits from itself.
+func (r *Role) EffectiveRoles() ([]*Role, error) {
 	var er []*Role
 
-	er = append(er, r)
-	for _, rs := range r.Roles {
-		iter++
-		sr, err := getEffectiveRoles(rs, iter)
-		if err != nil {
-			return nil, err
+	seen := make(map[string]bool)
+	visited := make(map[*Role]bool)
+
+	var walk func(*Role, []string) error
+	walk = func(role *Role, path []string) error {
+		for i, id := range path {
+			if id == role.ID {
+				return cycleError(append(path[i:], role.ID))
+			}
+		}
+
+		if visited[role] {
+			return nil
+		}
+
+		visited[role] = true
+		path = append(path, role.ID)
+
+		if !seen[role.ID] {
+			seen[role.ID] = true
+			er = append(er, role)
 		}
 
-		er = append(er, sr...)
+		for _, rs := range role.Roles {
+			if err := walk(rs, path); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(r, nil); err != nil {
+		return nil, err
 	}
 
 	return er, nil
 }
 
-// EffectiveRoles returns a flattened slice of all roles embedded in the Role.
-func (r *Role) EffectiveRoles() ([]*Role, error) {
-	return getEffectiveRoles(r, 0)
+func roleIDs(roles []*Role) []string {
+	ids := make([]string, 0, len(roles))
+	for _, r := range roles {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
 }
@@ -0,0 +1,303 @@
+package redtape
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SoDKind describes when a separation of duty constraint is enforced.
+type SoDKind string
+
+const (
+	// SoDStatic constraints prevent a subject from holding conflicting roles. They are enforced when roles
+	// are bound to a subject or combined through sub roles, and at evaluation time.
+	SoDStatic SoDKind = "static"
+	// SoDDynamic constraints allow a subject to hold conflicting roles but prevent a single request from
+	// activating them. They are enforced at evaluation time against the roles asserted by the request, its
+	// Role and the roles of its Subject, while roles bound to the subject are held but not activated.
+	SoDDynamic SoDKind = "dynamic"
+)
+
+// SoDConstraint is a separation of duty constraint allowing at most Max of Roles to be held or activated
+// together. A Max below 1 allows a single role.
+type SoDConstraint struct {
+	ID    string   `json:"id"`
+	Kind  SoDKind  `json:"kind"`
+	Roles []string `json:"roles"`
+	Max   int      `json:"max,omitempty"`
+}
+
+// NewStaticSoD returns a static SoDConstraint allowing only one of roles to be held by a subject.
+func NewStaticSoD(id string, roles ...string) SoDConstraint {
+	return SoDConstraint{ID: id, Kind: SoDStatic, Roles: roles, Max: 1}
+}
+
+// NewDynamicSoD returns a dynamic SoDConstraint allowing only one of roles to be activated by a request.
+func NewDynamicSoD(id string, roles ...string) SoDConstraint {
+	return SoDConstraint{ID: id, Kind: SoDDynamic, Roles: roles, Max: 1}
+}
+
+// Conflicts returns the constraint roles found in roles when there are more than Max of them.
+func (c SoDConstraint) Conflicts(roles []string) []string {
+	held := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		held[r] = true
+	}
+
+	conflicts := []string{}
+	for _, r := range c.Roles {
+		if held[r] {
+			conflicts = appendUnique(conflicts, r)
+		}
+	}
+
+	max := c.Max
+	if max < 1 {
+		max = 1
+	}
+
+	if len(conflicts) <= max {
+		return nil
+	}
+
+	return conflicts
+}
+
+// SoDViolation is the error returned when a SoDConstraint is violated. Subject is empty when the
+// violation was caused by combining roles rather than by a subject.
+type SoDViolation struct {
+	Constraint SoDConstraint `json:"constraint"`
+	Subject    string        `json:"subject,omitempty"`
+	Roles      []string      `json:"roles"`
+}
+
+func (v *SoDViolation) Error() string {
+	if v.Subject == "" {
+		return fmt.Sprintf("%s separation of duty constraint %s violated by roles %v", v.Constraint.Kind, v.Constraint.ID, v.Roles)
+	}
+
+	return fmt.Sprintf("%s separation of duty constraint %s violated by subject %s holding roles %v",
+		v.Constraint.Kind, v.Constraint.ID, v.Subject, v.Roles)
+}
+
+// SoDConstraints is a set of separation of duty constraints.
+type SoDConstraints []SoDConstraint
+
+// Check returns the first violation of a constraint of one of kinds by subject holding roles, or nil. All
+// constraints are checked when no kind is provided.
+func (cs SoDConstraints) Check(subject string, roles []string, kinds ...SoDKind) *SoDViolation {
+	for _, c := range cs {
+		if len(kinds) > 0 && !hasSoDKind(kinds, c.Kind) {
+			continue
+		}
+
+		if conflicts := c.Conflicts(roles); conflicts != nil {
+			return &SoDViolation{Constraint: c, Subject: subject, Roles: conflicts}
+		}
+	}
+
+	return nil
+}
+
+func hasSoDKind(kinds []SoDKind, kind SoDKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SoDAuditor is an optional interface for Auditors receiving separation of duty violations. The request is
+// nil for violations detected outside of an evaluation, such as binding a role.
+type SoDAuditor interface {
+	LogSoDViolation(req *Request, v *SoDViolation)
+}
+
+func auditSoD(a Auditor, req *Request, v *SoDViolation) {
+	if sa, ok := a.(SoDAuditor); ok {
+		sa.LogSoDViolation(req, v)
+	}
+}
+
+type sodRoleManager struct {
+	RoleManager
+
+	constraints SoDConstraints
+	auditor     Auditor
+	mu          sync.Mutex
+}
+
+// sodRoleNotifier is a sodRoleManager wrapping a RoleManager that implements RoleNotifier.
+type sodRoleNotifier struct {
+	*sodRoleManager
+	RoleNotifier
+}
+
+// NewSoDRoleManager returns a RoleManager enforcing the static constraints of cs on rm. Creating or updating a
+// role fails with a *SoDViolation when the role, or any role inheriting from it, would combine conflicting
+// roles through its sub roles. Violations are reported to auditor when it implements SoDAuditor. The
+// returned manager implements RoleNotifier when rm does.
+func NewSoDRoleManager(rm RoleManager, cs SoDConstraints, auditor Auditor) RoleManager {
+	m := &sodRoleManager{
+		RoleManager: rm,
+		constraints: cs,
+		auditor:     auditor,
+	}
+
+	if n, ok := rm.(RoleNotifier); ok {
+		return &sodRoleNotifier{sodRoleManager: m, RoleNotifier: n}
+	}
+
+	return m
+}
+
+// Create stores r unless it violates a static constraint.
+func (m *sodRoleManager) Create(r *Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.check(r); err != nil {
+		return err
+	}
+
+	return m.RoleManager.Create(r)
+}
+
+// Update stores r unless it violates a static constraint.
+func (m *sodRoleManager) Update(r *Role) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.check(r); err != nil {
+		return err
+	}
+
+	return m.RoleManager.Update(r)
+}
+
+func (m *sodRoleManager) check(r *Role) error {
+	roles, err := allRoles(m.RoleManager)
+	if err != nil {
+		return err
+	}
+
+	g, err := NewRoleGraph(roles...)
+	if err != nil {
+		return err
+	}
+
+	if err := g.Add(r); err != nil {
+		return err
+	}
+
+	for _, id := range append([]string{r.ID}, g.Ancestors(r.ID)...) {
+		if v := m.constraints.Check("", g.EffectiveRoles(id), SoDStatic); v != nil {
+			auditSoD(m.auditor, nil, v)
+			return v
+		}
+	}
+
+	return nil
+}
+
+const rolePageSize = 100
+
+// allRoles pages through RoleManager#All to load every stored role ordered by ID.
+func allRoles(rm RoleManager) ([]*Role, error) {
+	roles := []*Role{}
+
+	for offset := 0; ; offset += rolePageSize {
+		page, err := rm.All(rolePageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, page...)
+
+		if len(page) < rolePageSize {
+			sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+			return roles, nil
+		}
+	}
+}
+
+type sodRoleBindingManager struct {
+	RoleBindingManager
+
+	roles       RoleManager
+	constraints SoDConstraints
+	auditor     Auditor
+	mu          sync.Mutex
+}
+
+// NewSoDRoleBindingManager returns a RoleBindingManager enforcing the static constraints of cs on bm. Binding a
+// role fails with a *SoDViolation when the subject would hold conflicting roles among its active bindings.
+// Bound roles are expanded into their sub roles through rm when it is not nil. Violations are reported to
+// auditor when it implements SoDAuditor.
+func NewSoDRoleBindingManager(bm RoleBindingManager, rm RoleManager, cs SoDConstraints, auditor Auditor) RoleBindingManager {
+	return &sodRoleBindingManager{
+		RoleBindingManager: bm,
+		roles:              rm,
+		constraints:        cs,
+		auditor:            auditor,
+	}
+}
+
+// Bind stores b unless the subject would violate a static constraint.
+func (m *sodRoleBindingManager) Bind(b RoleBinding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if b.Active(now) {
+		bindings, err := m.RolesFor(b.SubjectID, now)
+		if err != nil {
+			return err
+		}
+
+		held := []string{}
+		for _, rb := range append(bindings, b) {
+			ids, err := m.expand(rb.RoleID)
+			if err != nil {
+				return err
+			}
+
+			held = append(held, ids...)
+		}
+
+		if v := m.constraints.Check(b.SubjectID, held, SoDStatic); v != nil {
+			auditSoD(m.auditor, nil, v)
+			return v
+		}
+	}
+
+	return m.RoleBindingManager.Bind(b)
+}
+
+func (m *sodRoleBindingManager) expand(id string) ([]string, error) {
+	if m.roles == nil {
+		return []string{id}, nil
+	}
+
+	role, err := m.roles.Get(id)
+	if errors.Is(err, ErrRoleNotFound) {
+		return []string{id}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	er, err := role.EffectiveRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	return roleIDs(er), nil
+}
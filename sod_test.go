@@ -0,0 +1,147 @@
+package redtape
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSoDConstraintConflicts(t *testing.T) {
+	payments := NewStaticSoD("payments", "payments_submit", "payments_approve", "payments_audit")
+
+	tests := []struct {
+		name       string
+		constraint SoDConstraint
+		roles      []string
+		want       []string
+	}{
+		{"none", payments, []string{"viewer"}, nil},
+		{"single", payments, []string{"viewer", "payments_submit"}, nil},
+		{"conflict", payments, []string{"payments_approve", "viewer", "payments_submit"}, []string{"payments_submit", "payments_approve"}},
+		{"duplicate_role", payments, []string{"payments_submit", "payments_submit"}, nil},
+		{"within_max", SoDConstraint{Roles: payments.Roles, Max: 2}, []string{"payments_submit", "payments_audit"}, nil},
+		{"above_max", SoDConstraint{Roles: payments.Roles, Max: 2}, payments.Roles, payments.Roles},
+		{"zero_max", SoDConstraint{Roles: payments.Roles}, []string{"payments_submit", "payments_audit"}, []string{"payments_submit", "payments_audit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.constraint.Conflicts(tt.roles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Conflicts(%v) = %v, want %v", tt.roles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoDConstraintsCheck(t *testing.T) {
+	cs := SoDConstraints{
+		NewStaticSoD("payments", "payments_submit", "payments_approve"),
+		NewDynamicSoD("deploy", "deploy_write", "deploy_review"),
+	}
+
+	tests := []struct {
+		name  string
+		roles []string
+		kinds []SoDKind
+		want  string
+	}{
+		{"clean", []string{"payments_submit", "deploy_write"}, nil, ""},
+		{"static", []string{"payments_submit", "payments_approve"}, nil, "payments"},
+		{"dynamic", []string{"deploy_write", "deploy_review"}, nil, "deploy"},
+		{"dynamic_skipped", []string{"deploy_write", "deploy_review"}, []SoDKind{SoDStatic}, ""},
+		{"static_only", []string{"payments_submit", "payments_approve"}, []SoDKind{SoDStatic}, "payments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := cs.Check("alice", tt.roles, tt.kinds...)
+
+			got := ""
+			if v != nil {
+				got = v.Constraint.ID
+			}
+
+			if got != tt.want {
+				t.Errorf("Check(%v) = %v, want constraint %q", tt.roles, v, tt.want)
+			}
+		})
+	}
+}
+
+// slowRoles widens the window between checking and storing roles.
+type slowRoles struct {
+	RoleManager
+}
+
+func (r slowRoles) All(limit, offset int) ([]*Role, error) {
+	roles, err := r.RoleManager.All(limit, offset)
+	time.Sleep(time.Millisecond)
+
+	return roles, err
+}
+
+// slowBindings widens the window between checking and storing bindings.
+type slowBindings struct {
+	RoleBindingManager
+}
+
+func (b slowBindings) RolesFor(subject string, at time.Time) ([]RoleBinding, error) {
+	bindings, err := b.RoleBindingManager.RolesFor(subject, at)
+	time.Sleep(time.Millisecond)
+
+	return bindings, err
+}
+
+// concurrently runs fns at once and returns their errors.
+func concurrently(fns ...func() error) []error {
+	errs := make([]error, len(fns))
+
+	var wg sync.WaitGroup
+
+	for i, fn := range fns {
+		wg.Add(1)
+
+		go func(i int, fn func() error) {
+			defer wg.Done()
+			errs[i] = fn()
+		}(i, fn)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+func TestSoDManagersConcurrent(t *testing.T) {
+	cs := SoDConstraints{NewStaticSoD("payments", "payments_submit", "payments_approve")}
+
+	rm := NewSoDRoleManager(slowRoles{NewRoleManager()}, cs, nil)
+	bm := NewSoDRoleBindingManager(slowBindings{NewRoleBindingManager()}, nil, cs, nil)
+
+	if err := rm.Create(NewRole("clerk")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rm.Create(NewRole("finance", NewRole("clerk"))); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := concurrently(
+		func() error { return rm.Update(NewRole("clerk", NewRole("payments_submit"))) },
+		func() error { return rm.Update(NewRole("finance", NewRole("clerk"), NewRole("payments_approve"))) },
+	)
+
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Errorf("conflicting role updates returned %v, want exactly one violation", errs)
+	}
+
+	errs = concurrently(
+		func() error { return bm.Bind(NewRoleBinding("alice", "payments_submit", nil)) },
+		func() error { return bm.Bind(NewRoleBinding("alice", "payments_approve", nil)) },
+	)
+
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Errorf("conflicting bindings returned %v, want exactly one violation", errs)
+	}
+}
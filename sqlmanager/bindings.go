@@ -0,0 +1,134 @@
+package sqlmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blushft/redtape"
+	"github.com/blushft/redtape/sqlmanager/ent"
+	rbent "github.com/blushft/redtape/sqlmanager/ent/rolebindings"
+)
+
+type sqlRoleBindingMgr struct {
+	client *ent.Client
+}
+
+// NewSqlRoleBindingManager returns an implementation of the ContextRoleBindingManager interface
+// with an ent client to make calls to the database.
+func NewSqlRoleBindingManager(opts ...SqlManagerOption) (redtape.ContextRoleBindingManager, error) {
+	c, err := openClient(NewSqlManagerOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlRoleBindingMgr{client: c}, nil
+}
+
+// Bind stores a role binding in the database, replacing an existing binding of the same subject and role.
+func (bm *sqlRoleBindingMgr) Bind(b redtape.RoleBinding) error {
+	return bm.BindContext(context.Background(), b)
+}
+
+// BindContext stores a role binding in the database using ctx.
+func (bm *sqlRoleBindingMgr) BindContext(ctx context.Context, b redtape.RoleBinding) error {
+	if b.SubjectID == "" || b.RoleID == "" {
+		return errors.New("role binding requires a subject and role")
+	}
+
+	existing, err := bm.client.RoleBindings.Query().
+		Where(rbent.SubjectID(b.SubjectID), rbent.RoleID(b.RoleID)).
+		Only(ctx)
+
+	switch {
+	case ent.IsNotFound(err):
+		_, err = bm.client.RoleBindings.Create().
+			SetSubjectID(b.SubjectID).
+			SetRoleID(b.RoleID).
+			SetNillableExpires(b.Expires).
+			Save(ctx)
+
+		return err
+	case err != nil:
+		return err
+	}
+
+	upd := existing.Update()
+	if b.Expires != nil {
+		upd.SetExpires(*b.Expires)
+	} else {
+		upd.ClearExpires()
+	}
+
+	_, err = upd.Save(ctx)
+
+	return err
+}
+
+// Unbind removes a role binding from the database.
+func (bm *sqlRoleBindingMgr) Unbind(subject, role string) error {
+	return bm.UnbindContext(context.Background(), subject, role)
+}
+
+// UnbindContext removes a role binding from the database using ctx.
+func (bm *sqlRoleBindingMgr) UnbindContext(ctx context.Context, subject, role string) error {
+	n, err := bm.client.RoleBindings.Delete().
+		Where(rbent.SubjectID(subject), rbent.RoleID(role)).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("subject %s is not bound to role %s", subject, role)
+	}
+
+	return nil
+}
+
+// RolesFor returns the bindings of a subject active at the provided time.
+func (bm *sqlRoleBindingMgr) RolesFor(subject string, at time.Time) ([]redtape.RoleBinding, error) {
+	return bm.RolesForContext(context.Background(), subject, at)
+}
+
+// RolesForContext returns the bindings of a subject active at the provided time using ctx.
+func (bm *sqlRoleBindingMgr) RolesForContext(ctx context.Context, subject string, at time.Time) ([]redtape.RoleBinding, error) {
+	bindings, err := bm.client.RoleBindings.Query().
+		Where(rbent.SubjectID(subject), rbent.Or(rbent.ExpiresIsNil(), rbent.ExpiresGT(at))).
+		Order(ent.Asc(rbent.FieldRoleID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entBindingsToTape(bindings), nil
+}
+
+// SubjectsFor returns the bindings of a role active at the provided time.
+func (bm *sqlRoleBindingMgr) SubjectsFor(role string, at time.Time) ([]redtape.RoleBinding, error) {
+	return bm.SubjectsForContext(context.Background(), role, at)
+}
+
+// SubjectsForContext returns the bindings of a role active at the provided time using ctx.
+func (bm *sqlRoleBindingMgr) SubjectsForContext(ctx context.Context, role string, at time.Time) ([]redtape.RoleBinding, error) {
+	bindings, err := bm.client.RoleBindings.Query().
+		Where(rbent.RoleID(role), rbent.Or(rbent.ExpiresIsNil(), rbent.ExpiresGT(at))).
+		Order(ent.Asc(rbent.FieldSubjectID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entBindingsToTape(bindings), nil
+}
+
+// Translate ent's RoleBindings to redtape's RoleBinding.
+func entBindingsToTape(bindings []*ent.RoleBindings) []redtape.RoleBinding {
+	result := make([]redtape.RoleBinding, 0, len(bindings))
+	for _, b := range bindings {
+		result = append(result, redtape.NewRoleBinding(b.SubjectID, b.RoleID, b.Expires))
+	}
+
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+	"github.com/blushft/redtape"
 )
 
 // PolicyOptions holds the schema definition for the PolicyOptions entity.
@@ -17,10 +18,18 @@ func (PolicyOptions) Fields() []ent.Field {
 		field.String("id"),
 		field.String("name"),
 		field.String("description"),
+		field.Strings("subjects").Optional(),
 		field.Strings("resources"),
 		field.Strings("actions"),
 		field.Strings("scopes"),
 		field.String("effect"),
+		field.String("mode").Default("enforce"),
+		field.Int("priority").Default(0),
+		field.JSON("obligations", []redtape.Obligation{}).Optional(),
+		field.JSON("advice", []redtape.Obligation{}).Optional(),
+		field.Time("not_before").Optional().Nillable(),
+		field.Time("not_after").Optional().Nillable(),
+		field.JSON("schedules", []redtape.Schedule{}).Optional(),
 	}
 }
 
@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// RoleBindings holds the schema definition for the RoleBindings entity.
+type RoleBindings struct {
+	ent.Schema
+}
+
+// Fields of the RoleBindings.
+func (RoleBindings) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("subject_id"),
+		field.String("role_id"),
+		field.Time("expires").Optional().Nillable(),
+	}
+}
+
+// Indexes of the RoleBindings.
+func (RoleBindings) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("subject_id", "role_id").Unique(),
+	}
+}
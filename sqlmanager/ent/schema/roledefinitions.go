@@ -0,0 +1,22 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"github.com/blushft/redtape"
+)
+
+// RoleDefinitions holds the schema definition for the RoleDefinitions entity managed by the role manager.
+type RoleDefinitions struct {
+	ent.Schema
+}
+
+// Fields of the RoleDefinitions.
+func (RoleDefinitions) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id"),
+		field.String("name"),
+		field.String("description"),
+		field.JSON("sub_roles", []*redtape.Role{}).Optional(),
+	}
+}
@@ -1,8 +1,12 @@
 package sqlmanager
 
+import "github.com/blushft/redtape"
+
 type SqlManagerOptions struct {
 	Dialect    string
 	ConnString string
+	Matcher    redtape.Matcher
+	Registry   redtape.ConditionRegistry
 }
 
 type SqlManagerOption func(*SqlManagerOptions)
@@ -31,3 +35,19 @@ func SetConnString(conn string) SqlManagerOption {
 		o.ConnString = conn
 	}
 }
+
+// SetMatcher sets the Matcher used by the role manager to match role patterns. The DefaultMatcher is used
+// when it is not set.
+func SetMatcher(m redtape.Matcher) SqlManagerOption {
+	return func(o *SqlManagerOptions) {
+		o.Matcher = m
+	}
+}
+
+// SetConditionRegistry sets the ConditionRegistry used to build the conditions of stored policies. The default
+// ConditionRegistry is used when it is not set.
+func SetConditionRegistry(reg redtape.ConditionRegistry) SqlManagerOption {
+	return func(o *SqlManagerOptions) {
+		o.Registry = reg
+	}
+}
@@ -0,0 +1,211 @@
+package sqlmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blushft/redtape"
+	"github.com/blushft/redtape/sqlmanager/ent"
+	rdent "github.com/blushft/redtape/sqlmanager/ent/roledefinitions"
+)
+
+type sqlRoleMgr struct {
+	redtape.RoleListeners
+
+	client  *ent.Client
+	matcher redtape.Matcher
+}
+
+// NewSqlRoleManager returns an implementation of the ContextRoleManager interface
+// with an ent client to make calls to the database. Sub roles are stored along with each role.
+// The returned manager also implements redtape.RoleNotifier.
+func NewSqlRoleManager(opts ...SqlManagerOption) (redtape.ContextRoleManager, error) {
+	options := NewSqlManagerOptions(opts...)
+
+	c, err := openClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlRoleMgr{client: c, matcher: options.Matcher}, nil
+}
+
+// Create creates a role in the database.
+func (rm *sqlRoleMgr) Create(r *redtape.Role) error {
+	return rm.CreateContext(context.Background(), r)
+}
+
+// CreateContext creates a role in the database using ctx.
+func (rm *sqlRoleMgr) CreateContext(ctx context.Context, r *redtape.Role) error {
+	if err := rm.checkRole(ctx, r); err != nil {
+		return err
+	}
+
+	_, err := rm.client.RoleDefinitions.Create().
+		SetID(r.ID).
+		SetName(r.Name).
+		SetDescription(r.Description).
+		SetSubRoles(r.Roles).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	rm.NotifyRoles(redtape.RoleCreated, r.ID)
+
+	return nil
+}
+
+// Update updates a role given its ID.
+func (rm *sqlRoleMgr) Update(r *redtape.Role) error {
+	return rm.UpdateContext(context.Background(), r)
+}
+
+// UpdateContext updates a role given its ID using ctx.
+func (rm *sqlRoleMgr) UpdateContext(ctx context.Context, r *redtape.Role) error {
+	if err := rm.checkRole(ctx, r); err != nil {
+		return err
+	}
+
+	_, err := rm.client.RoleDefinitions.UpdateOneID(r.ID).
+		SetName(r.Name).
+		SetDescription(r.Description).
+		SetSubRoles(r.Roles).
+		Save(ctx)
+	if ent.IsNotFound(err) {
+		return fmt.Errorf("%w: %s", redtape.ErrRoleNotFound, r.ID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rm.NotifyRoles(redtape.RoleUpdated, r.ID)
+
+	return nil
+}
+
+// checkRole returns an error when storing r would form a role cycle with the stored roles.
+func (rm *sqlRoleMgr) checkRole(ctx context.Context, r *redtape.Role) error {
+	roles, err := rm.client.RoleDefinitions.Query().All(ctx)
+	if err != nil {
+		return err
+	}
+
+	return redtape.CheckRoleGraph(r, entRoleDefsToTape(roles)...)
+}
+
+// Get retrieves a role given its ID.
+func (rm *sqlRoleMgr) Get(id string) (*redtape.Role, error) {
+	return rm.GetContext(context.Background(), id)
+}
+
+// GetContext retrieves a role given its ID using ctx.
+func (rm *sqlRoleMgr) GetContext(ctx context.Context, id string) (*redtape.Role, error) {
+	r, err := rm.client.RoleDefinitions.Get(ctx, id)
+	if ent.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", redtape.ErrRoleNotFound, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entRoleDefToTape(r), nil
+}
+
+// GetByName retrieves a role given its name.
+func (rm *sqlRoleMgr) GetByName(name string) (*redtape.Role, error) {
+	return rm.GetByNameContext(context.Background(), name)
+}
+
+// GetByNameContext retrieves a role given its name using ctx.
+func (rm *sqlRoleMgr) GetByNameContext(ctx context.Context, name string) (*redtape.Role, error) {
+	r, err := rm.client.RoleDefinitions.Query().
+		Where(rdent.Name(name)).
+		First(ctx)
+	if ent.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: %s", redtape.ErrRoleNotFound, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entRoleDefToTape(r), nil
+}
+
+// Delete deletes a role given its ID.
+func (rm *sqlRoleMgr) Delete(id string) error {
+	return rm.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext deletes a role given its ID using ctx.
+func (rm *sqlRoleMgr) DeleteContext(ctx context.Context, id string) error {
+	_, err := rm.client.RoleDefinitions.Delete().
+		Where(rdent.ID(id)).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	rm.NotifyRoles(redtape.RoleDeleted, id)
+
+	return nil
+}
+
+// All returns a page of roles ordered by ID.
+func (rm *sqlRoleMgr) All(limit, offset int) ([]*redtape.Role, error) {
+	return rm.AllContext(context.Background(), limit, offset)
+}
+
+// AllContext returns a page of roles ordered by ID using ctx.
+func (rm *sqlRoleMgr) AllContext(ctx context.Context, limit, offset int) ([]*redtape.Role, error) {
+	roles, err := rm.client.RoleDefinitions.Query().
+		Order(ent.Asc(rdent.FieldID)).
+		Limit(limit).
+		Offset(offset).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entRoleDefsToTape(roles), nil
+}
+
+// GetMatching returns a page of roles ordered by ID whose ID matches pattern using the configured Matcher.
+func (rm *sqlRoleMgr) GetMatching(pattern string, limit, offset int) ([]*redtape.Role, error) {
+	return rm.GetMatchingContext(context.Background(), pattern, limit, offset)
+}
+
+// GetMatchingContext returns a page of roles ordered by ID whose ID matches pattern using the configured
+// Matcher and ctx. Patterns are evaluated by the Matcher rather than the database, so every role is loaded.
+func (rm *sqlRoleMgr) GetMatchingContext(ctx context.Context, pattern string, limit, offset int) ([]*redtape.Role, error) {
+	roles, err := rm.client.RoleDefinitions.Query().
+		Order(ent.Asc(rdent.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return redtape.MatchRoles(rm.matcher, entRoleDefsToTape(roles), pattern, limit, offset)
+}
+
+// Translate ent's RoleDefinitions to redtape's Role.
+func entRoleDefToTape(r *ent.RoleDefinitions) *redtape.Role {
+	return &redtape.Role{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Roles:       r.SubRoles,
+	}
+}
+
+func entRoleDefsToTape(roles []*ent.RoleDefinitions) []*redtape.Role {
+	result := make([]*redtape.Role, 0, len(roles))
+	for _, r := range roles {
+		result = append(result, entRoleDefToTape(r))
+	}
+
+	return result
+}
@@ -2,8 +2,7 @@ package sqlmanager
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"time"
 
 	"github.com/blushft/redtape"
 	"github.com/blushft/redtape/sqlmanager/ent"
@@ -14,16 +13,28 @@ import (
 )
 
 type sqlPolicyMgr struct {
-	client *ent.Client
+	redtape.PolicyListeners
+
+	client   *ent.Client
+	registry redtape.ConditionRegistry
 }
 
-// NewSqlManager returns an implementation of the PolicyManager interface
-// with an ent client to make calls to the database.
-func NewSqlManager(opts ...SqlManagerOption) (redtape.PolicyManager, error) {
+// NewSqlManager returns an implementation of the ContextPolicyManager interface
+// with an ent client to make calls to the database. Policies are returned in descending priority order.
+// The returned manager also implements redtape.ExpiredPolicyFinder and redtape.PolicyNotifier.
+func NewSqlManager(opts ...SqlManagerOption) (redtape.ContextPolicyManager, error) {
 	options := NewSqlManagerOptions(opts...)
 
-	pm := &sqlPolicyMgr{}
+	c, err := openClient(options)
+	if err != nil {
+		return nil, err
+	}
 
+	return &sqlPolicyMgr{client: c, registry: options.Registry}, nil
+}
+
+// openClient opens an ent client for the configured database and migrates the schema.
+func openClient(options SqlManagerOptions) (*ent.Client, error) {
 	c, err := ent.Open(options.Dialect, options.ConnString)
 	if err != nil {
 		return nil, err
@@ -33,16 +44,17 @@ func NewSqlManager(opts ...SqlManagerOption) (redtape.PolicyManager, error) {
 		return nil, err
 	}
 
-	pm.client = c
-
-	return pm, nil
+	return c, nil
 }
 
 // Create creates a policy in the database.
 func (pm *sqlPolicyMgr) Create(p redtape.Policy) error {
-	// Let's first insert roles/conditions in order to create the edges.
-	ctx := context.Background()
+	return pm.CreateContext(context.Background(), p)
+}
 
+// CreateContext creates a policy in the database using ctx.
+func (pm *sqlPolicyMgr) CreateContext(ctx context.Context, p redtape.Policy) error {
+	// Let's first insert roles/conditions in order to create the edges.
 	roles, conditions, err := pm.createConditionsRoles(p.Roles(), p.Conditions(), ctx)
 	if err != nil {
 		return err
@@ -53,10 +65,18 @@ func (pm *sqlPolicyMgr) Create(p redtape.Policy) error {
 		SetID(p.ID()).
 		SetName(p.Name()).
 		SetDescription(p.Description()).
+		SetSubjects(p.Subjects()).
 		SetResources(p.Resources()).
 		SetActions(p.Actions()).
 		SetScopes(p.Scopes()).
 		SetEffect(string(p.Effect())).
+		SetMode(string(p.Mode())).
+		SetPriority(p.Priority()).
+		SetObligations(p.Obligations()).
+		SetAdvice(p.Advice()).
+		SetNillableNotBefore(p.Validity().NotBefore).
+		SetNillableNotAfter(p.Validity().NotAfter).
+		SetSchedules(p.Validity().Schedules).
 		AddRoles(roles...).
 		AddConditions(conditions...).
 		Save(ctx)
@@ -64,14 +84,19 @@ func (pm *sqlPolicyMgr) Create(p redtape.Policy) error {
 		return err
 	}
 
+	pm.Notify(redtape.PolicyCreated, p.ID())
+
 	return nil
 }
 
 // Update updates a policy given an ID.
 func (pm *sqlPolicyMgr) Update(p redtape.Policy) error {
-	// Delete current conditions and roles associated with this policy first.
-	ctx := context.Background()
+	return pm.UpdateContext(context.Background(), p)
+}
 
+// UpdateContext updates a policy given an ID using ctx.
+func (pm *sqlPolicyMgr) UpdateContext(ctx context.Context, p redtape.Policy) error {
+	// Delete current conditions and roles associated with this policy first.
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles().
@@ -102,160 +127,182 @@ func (pm *sqlPolicyMgr) Update(p redtape.Policy) error {
 	}
 
 	// Update policy.
-	_, err = pm.client.PolicyOptions.UpdateOneID(p.ID()).
+	v := p.Validity()
+	upd := pm.client.PolicyOptions.UpdateOneID(p.ID())
+
+	if v.NotBefore != nil {
+		upd.SetNotBefore(*v.NotBefore)
+	} else {
+		upd.ClearNotBefore()
+	}
+
+	if v.NotAfter != nil {
+		upd.SetNotAfter(*v.NotAfter)
+	} else {
+		upd.ClearNotAfter()
+	}
+
+	_, err = upd.
 		SetName(p.Name()).
 		SetDescription(p.Description()).
+		SetSubjects(p.Subjects()).
 		SetResources(p.Resources()).
 		SetActions(p.Actions()).
 		SetScopes(p.Scopes()).
 		SetEffect(string(p.Effect())).
+		SetMode(string(p.Mode())).
+		SetPriority(p.Priority()).
+		SetObligations(p.Obligations()).
+		SetAdvice(p.Advice()).
+		SetSchedules(v.Schedules).
 		AddConditions(conditions...).
 		AddRoles(roles...).
-		Save(context.Background())
+		Save(ctx)
 	if err != nil {
 		return err
 	}
 
+	pm.Notify(redtape.PolicyUpdated, p.ID())
+
 	return nil
 }
 
 // Get gets a policy from the database given an ID.
 func (pm *sqlPolicyMgr) Get(id string) (redtape.Policy, error) {
+	return pm.GetContext(context.Background(), id)
+}
+
+// GetContext gets a policy from the database given an ID using ctx.
+func (pm *sqlPolicyMgr) GetContext(ctx context.Context, id string) (redtape.Policy, error) {
 	policy, err := pm.client.PolicyOptions.Query().
 		Where(poent.ID(id)).
 		WithConditions().
 		WithRoles().
-		First(context.Background())
+		First(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return entPolicyToTape(policy), nil
+	return pm.entPolicyToTape(policy)
 }
 
 // Delete will delete a policy from the database given an ID.
 func (pm *sqlPolicyMgr) Delete(id string) error {
-	return pm.client.PolicyOptions.DeleteOneID(id).Exec(context.Background())
+	return pm.DeleteContext(context.Background(), id)
 }
 
+// DeleteContext will delete a policy from the database given an ID using ctx.
+func (pm *sqlPolicyMgr) DeleteContext(ctx context.Context, id string) error {
+	if err := pm.client.PolicyOptions.DeleteOneID(id).Exec(ctx); err != nil {
+		return err
+	}
+
+	pm.Notify(redtape.PolicyDeleted, id)
+
+	return nil
+}
+
+// All returns a page of policies from the database.
 func (pm *sqlPolicyMgr) All(limit, offset int) ([]redtape.Policy, error) {
+	return pm.AllContext(context.Background(), limit, offset)
+}
+
+// AllContext returns a page of policies from the database using ctx.
+func (pm *sqlPolicyMgr) AllContext(ctx context.Context, limit, offset int) ([]redtape.Policy, error) {
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles().
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
 		Limit(limit).
 		Offset(offset).
-		All(context.Background())
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := []redtape.Policy{}
-	for _, p := range policies {
-		result = append(result, entPolicyToTape(p))
-	}
-
-	return result, nil
+	return pm.entPoliciesToTape(policies)
 }
 
-// FindByRequest will search the database for a policy that has the exact same data as the request.
-func (pm *sqlPolicyMgr) FindByRequest(req *redtape.Request) ([]redtape.Policy, error) {
-	if req.Resource == "" || req.Action == "" || req.Scope == "" || req.Role == "" {
-		return nil, errors.New(fmt.Sprintf("Request had an empty field: %v", req))
-	}
+// FindExpired returns the policies from the database whose validity window ended before at.
+func (pm *sqlPolicyMgr) FindExpired(at time.Time) ([]redtape.Policy, error) {
+	return pm.FindExpiredContext(context.Background(), at)
+}
 
+// FindExpiredContext returns the policies from the database whose validity window ended before at using ctx.
+func (pm *sqlPolicyMgr) FindExpiredContext(ctx context.Context, at time.Time) ([]redtape.Policy, error) {
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles().
-		All(context.Background())
+		Where(poent.NotAfterLT(at)).
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := []redtape.Policy{}
-
-	// Traverse each policy.
-	for _, p := range policies {
-		found := false
-
-		// Check if any of the resources are the same.
-		for _, r := range p.Resources {
-			if r == req.Resource {
-				found = true
-				break
-			}
-		}
-
-		// If any time the req's data isn't found, we can just not return this policy.
-		if found {
-			for i, a := range p.Actions {
-				if a == req.Action {
-					break
-				} else if i == len(p.Actions)-1 {
-					found = false
-				}
-			}
-		}
-
-		if found {
-			for i, s := range p.Scopes {
-				if s == req.Scope {
-					break
-				} else if i == len(p.Scopes)-1 {
-					found = false
-				}
-			}
-		}
+	return pm.entPoliciesToTape(policies)
+}
 
-		if found {
-			for i, role := range p.Edges.Roles {
-				if role.ID == req.Role {
-					break
-				} else if i == len(p.Edges.Roles)-1 {
-					found = false
-				}
-			}
-		}
+// FindByRequest returns the candidate policies from the database for a request.
+func (pm *sqlPolicyMgr) FindByRequest(req *redtape.Request) ([]redtape.Policy, error) {
+	return pm.FindByRequestContext(context.Background(), req)
+}
 
-		if found {
-			result = append(result, entPolicyToTape(p))
-		}
+// FindByRequestContext returns the candidate policies from the database for a request using ctx. Request
+// roles may come from role bindings or role inheritance and resources may be patterns or templates, which
+// only the Enforcer resolves, so every policy is a candidate and matching is left to its Matcher.
+func (pm *sqlPolicyMgr) FindByRequestContext(ctx context.Context, _ *redtape.Request) ([]redtape.Policy, error) {
+	policies, err := pm.client.PolicyOptions.Query().
+		WithConditions().
+		WithRoles().
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return pm.entPoliciesToTape(policies)
 }
 
 // FindByRole will return a policy from the database with the same role name.
 func (pm *sqlPolicyMgr) FindByRole(role string) ([]redtape.Policy, error) {
+	return pm.FindByRoleContext(context.Background(), role)
+}
+
+// FindByRoleContext will return a policy from the database with the same role name using ctx.
+func (pm *sqlPolicyMgr) FindByRoleContext(ctx context.Context, role string) ([]redtape.Policy, error) {
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles(func(q *ent.RolesQuery) {
 			q.Where(rent.Name(role))
 		}).
-		All(context.Background())
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := []redtape.Policy{}
-
-	for _, p := range policies {
-		result = append(result, entPolicyToTape(p))
-	}
-
-	return result, nil
+	return pm.entPoliciesToTape(policies)
 }
 
 // FindByResource will return a policy from the database that has the resource in the resources field.
 func (pm *sqlPolicyMgr) FindByResource(resource string) ([]redtape.Policy, error) {
+	return pm.FindByResourceContext(context.Background(), resource)
+}
+
+// FindByResourceContext will return a policy from the database that has the resource in the resources
+// field using ctx.
+func (pm *sqlPolicyMgr) FindByResourceContext(ctx context.Context, resource string) ([]redtape.Policy, error) {
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles().
-		All(context.Background())
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := []redtape.Policy{}
+	result := []*ent.PolicyOptions{}
 
 	for _, p := range policies {
 		found := false
@@ -267,24 +314,30 @@ func (pm *sqlPolicyMgr) FindByResource(resource string) ([]redtape.Policy, error
 		}
 
 		if found {
-			result = append(result, entPolicyToTape(p))
+			result = append(result, p)
 		}
 	}
 
-	return result, nil
+	return pm.entPoliciesToTape(result)
 }
 
 // FindByResource will return a policy from the database that has the scope in the scopes field.
 func (pm *sqlPolicyMgr) FindByScope(scope string) ([]redtape.Policy, error) {
+	return pm.FindByScopeContext(context.Background(), scope)
+}
+
+// FindByScopeContext will return a policy from the database that has the scope in the scopes field using ctx.
+func (pm *sqlPolicyMgr) FindByScopeContext(ctx context.Context, scope string) ([]redtape.Policy, error) {
 	policies, err := pm.client.PolicyOptions.Query().
 		WithConditions().
 		WithRoles().
-		All(context.Background())
+		Order(ent.Desc(poent.FieldPriority), ent.Asc(poent.FieldID)).
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := []redtape.Policy{}
+	result := []*ent.PolicyOptions{}
 
 	for _, p := range policies {
 		found := false
@@ -296,11 +349,11 @@ func (pm *sqlPolicyMgr) FindByScope(scope string) ([]redtape.Policy, error) {
 		}
 
 		if found {
-			result = append(result, entPolicyToTape(p))
+			result = append(result, p)
 		}
 	}
 
-	return result, nil
+	return pm.entPoliciesToTape(result)
 }
 
 // Creates Conditions and Roles in the database and returns their ent reference.
@@ -322,16 +375,11 @@ func (pm *sqlPolicyMgr) createConditionsRoles(roles []*redtape.Role, conditions
 		entRoles = append(entRoles, r)
 	}
 
-	for name, cond := range conditions {
-		typ, val := getTypeAndVal(cond)
-		opts := map[string]interface{}{
-			"value": val,
-		}
-
+	for _, co := range conditions.Options() {
 		c, err := pm.client.Conditions.Create().
-			SetName(name).
-			SetType(typ).
-			SetOptions(opts).
+			SetName(co.Name).
+			SetType(co.Type).
+			SetOptions(co.Options).
 			Save(ctx)
 		if err != nil {
 			return nil, nil, err
@@ -343,16 +391,39 @@ func (pm *sqlPolicyMgr) createConditionsRoles(roles []*redtape.Role, conditions
 	return entRoles, entConds, nil
 }
 
-// Translate ent's Policy to redtape's Policy.
-func entPolicyToTape(p *ent.PolicyOptions) redtape.Policy {
+// Translate ent's Policies to redtape's Policies.
+func (pm *sqlPolicyMgr) entPoliciesToTape(policies []*ent.PolicyOptions) ([]redtape.Policy, error) {
+	result := make([]redtape.Policy, 0, len(policies))
+	for _, p := range policies {
+		rp, err := pm.entPolicyToTape(p)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, rp)
+	}
+
+	return result, nil
+}
+
+// Translate ent's Policy to redtape's Policy, building its conditions from the configured registry.
+func (pm *sqlPolicyMgr) entPolicyToTape(p *ent.PolicyOptions) (redtape.Policy, error) {
 	po := redtape.PolicyOptions{
 		ID:          p.ID,
 		Name:        p.Name,
 		Description: p.Description,
+		Subjects:    p.Subjects,
 		Resources:   p.Resources,
 		Actions:     p.Actions,
 		Scopes:      p.Scopes,
 		Effect:      p.Effect,
+		Mode:        p.Mode,
+		Priority:    p.Priority,
+		Obligations: p.Obligations,
+		Advice:      p.Advice,
+		NotBefore:   p.NotBefore,
+		NotAfter:    p.NotAfter,
+		Schedules:   p.Schedules,
 	}
 
 	rtRoles := []*redtape.Role{}
@@ -368,7 +439,7 @@ func entPolicyToTape(p *ent.PolicyOptions) redtape.Policy {
 	po.Roles = rtRoles
 	po.Conditions = rtConds
 
-	return redtape.MustNewPolicy(redtape.SetPolicyOptions(po))
+	return redtape.NewPolicy(redtape.SetPolicyOptions(po), redtape.SetConditionRegistry(pm.registry))
 }
 
 // Translate ent's Role to redtape's Role.
@@ -388,16 +459,3 @@ func entCondToTape(cond *ent.Conditions) redtape.ConditionOptions {
 		Options: cond.Options,
 	}
 }
-
-// Returns condition's type and its value.
-func getTypeAndVal(val interface{}) (string, bool) {
-	// TODO: RoleEqualsCondition doesn't have a value right now.
-	switch v := val.(type) {
-	case *redtape.BoolCondition:
-		return v.Name(), v.Value
-	case *redtape.RoleEqualsCondition:
-		return v.Name(), false
-	default:
-		return "", false
-	}
-}
@@ -3,6 +3,7 @@ package sqlmanager
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/blushft/redtape"
 	"github.com/google/uuid"
@@ -158,32 +159,43 @@ func (s *SqlManagerSuite) TestBPolicyOptions() {
 	// find by request
 	req := redtape.NewRequest(opts.Resources[0], opts.Actions[0], opts.Roles[0].ID, opts.Scopes[0])
 
-	policies, err := man.FindByRequest(req)
-	s.Require().NoError(err)
+	candidates := func(req *redtape.Request) []string {
+		policies, err := man.FindByRequest(req)
+		s.Require().NoError(err)
 
-	s.Require().GreaterOrEqual(len(policies), 0, "should be at least one policy")
+		ids := make([]string, 0, len(policies))
+		for _, p := range policies {
+			ids = append(ids, p.ID())
+		}
 
-	s.Require().True(reflect.DeepEqual(policy, policies[0]))
+		return ids
+	}
 
-	// find by empty request
-	policies, err = man.FindByRequest(&redtape.Request{})
-	s.Require().Error(err)
+	s.Require().Contains(candidates(req), opts.ID)
 
-	s.Require().Equal(len(policies), 0, "should not have found a policy")
-
-	// find by random request
-	policies, err = man.FindByRequest(redtape.NewRequest(opts.Resources[0], "random", "random", "random"))
-	s.Require().NoError(err)
+	// roles bound to a subject and resource patterns are left to the matcher
+	s.Require().Contains(candidates(redtape.NewSubjectRequest(
+		opts.Resources[0], opts.Actions[0], redtape.NewSubject("Test Subject"), opts.Scopes[0],
+	)), opts.ID)
+	s.Require().Contains(candidates(&redtape.Request{}), opts.ID)
 
-	s.Require().Equal(len(policies), 0, "should not have found a policy 2")
+	bm := redtape.NewRoleBindingManager()
+	s.Require().NoError(bm.Bind(redtape.NewRoleBinding("Test Subject", opts.Roles[0].ID, nil)))
 
-	policies, err = man.FindByRequest(redtape.NewRequest("random", opts.Actions[0], "random", "random"))
+	e, err := redtape.NewEnforcerWithOptions(man, redtape.SetRoleBindingManager(bm))
 	s.Require().NoError(err)
 
-	s.Require().Equal(len(policies), 0, "should not have found a policy 3")
+	meta := map[string]interface{}{"test_cond": true}
+
+	s.Require().NoError(e.Enforce(redtape.NewSubjectRequest(
+		opts.Resources[0], opts.Actions[0], redtape.NewSubject("Test Subject"), opts.Scopes[0], meta,
+	)))
+	s.Require().Error(e.Enforce(redtape.NewSubjectRequest(
+		opts.Resources[0], opts.Actions[0], redtape.NewSubject("Other Subject"), opts.Scopes[0], meta,
+	)))
 
 	// find by resource
-	policies, err = man.FindByResource(req.Resource)
+	policies, err := man.FindByResource(req.Resource)
 	s.Require().NoError(err)
 
 	s.Require().True(reflect.DeepEqual(policy, policies[0]))
@@ -194,3 +206,72 @@ func (s *SqlManagerSuite) TestBPolicyOptions() {
 
 	s.Require().True(reflect.DeepEqual(policy, policies[0]))
 }
+
+func (s *SqlManagerSuite) TestCRoleBindings() {
+	bm, err := NewSqlRoleBindingManager(
+		SetDialect("postgres"),
+		SetConnString("host=localhost port=5432 user=admin dbname=policy password=password sslmode=disable"),
+	)
+	s.Require().NoError(err)
+
+	subject := uuid.NewString()
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	s.Require().NoError(bm.Bind(redtape.NewRoleBinding(subject, "editor", nil)))
+	s.Require().NoError(bm.Bind(redtape.NewRoleBinding(subject, "oncall", &expires)))
+
+	roles, err := bm.RolesFor(subject, now)
+	s.Require().NoError(err)
+	s.Require().Len(roles, 2)
+
+	roles, err = bm.RolesFor(subject, expires.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(roles, 1)
+	s.Require().Equal("editor", roles[0].RoleID)
+
+	subjects, err := bm.SubjectsFor("oncall", now)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(subjects)
+
+	s.Require().NoError(bm.Unbind(subject, "editor"))
+	s.Require().Error(bm.Unbind(subject, "editor"))
+	s.Require().NoError(bm.Unbind(subject, "oncall"))
+}
+
+func (s *SqlManagerSuite) TestDRoles() {
+	rm, err := NewSqlRoleManager(
+		SetDialect("postgres"),
+		SetConnString("host=localhost port=5432 user=admin dbname=policy password=password sslmode=disable"),
+	)
+	s.Require().NoError(err)
+
+	prefix := uuid.NewString()
+	viewer := redtape.NewRole(prefix + "_viewer")
+	editor := redtape.NewRole(prefix+"_editor", viewer)
+
+	s.Require().NoError(rm.Create(viewer))
+	s.Require().NoError(rm.Create(editor))
+
+	role, err := rm.Get(editor.ID)
+	s.Require().NoError(err)
+	s.Require().True(reflect.DeepEqual(editor, role))
+
+	_, err = rm.Get(prefix + "_missing")
+	s.Require().ErrorIs(err, redtape.ErrRoleNotFound)
+
+	s.Require().ErrorIs(rm.Update(redtape.NewRole(viewer.ID, editor)), redtape.ErrRoleCycle)
+
+	matching, err := rm.GetMatching(prefix+"_*", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(matching, 2)
+	s.Require().Equal(editor.ID, matching[0].ID)
+
+	matching, err = rm.GetMatching(prefix+"_*", 1, 1)
+	s.Require().NoError(err)
+	s.Require().Len(matching, 1)
+	s.Require().Equal(viewer.ID, matching[0].ID)
+
+	s.Require().NoError(rm.Delete(editor.ID))
+	s.Require().NoError(rm.Delete(viewer.ID))
+}
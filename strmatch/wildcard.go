@@ -1,5 +1,7 @@
 package strmatch
 
+import "strings"
+
 // MatchWildcard evaluates to true when the given value matches the search string by wildcard.
 func MatchWildcard(search, val string) bool {
 	return matchWildcard(search, val, false)
@@ -46,3 +48,25 @@ func runeSearch(val, search []rune, simple bool) bool {
 
 	return len(val) == 0 && len(search) == 0
 }
+
+// WildcardToLike translates a wildcard search string to a SQL LIKE pattern. '*' becomes '%', '?' becomes '_'
+// and literal occurrences of '%', '_' and the escape rune are escaped with escape.
+func WildcardToLike(search string, escape rune) string {
+	var sb strings.Builder
+
+	for _, r := range search {
+		switch r {
+		case '*':
+			sb.WriteRune('%')
+		case '?':
+			sb.WriteRune('_')
+		case '%', '_', escape:
+			sb.WriteRune(escape)
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
@@ -29,3 +29,22 @@ func TestMatchSimpleWildcard(t *testing.T) {
 		})
 	}
 }
+
+func TestWildcardToLike(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   string
+	}{
+		{name: "star", search: "/docs/*", want: "/docs/%"},
+		{name: "question", search: "file?.txt", want: "file_.txt"},
+		{name: "escape", search: "100%_done\\*", want: "100\\%\\_done\\\\%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WildcardToLike(tt.search, '\\'); got != tt.want {
+				t.Errorf("WildcardToLike() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
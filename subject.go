@@ -0,0 +1,41 @@
+package redtape
+
+// Subject describes the principal making a request. Roles holds the roles granted to the subject, which are
+// expanded through Role#EffectiveRoles when matching policies, and Attributes can carry additional
+// information about the subject.
+type Subject struct {
+	ID         string                 `json:"id"`
+	Roles      []*Role                `json:"roles,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// NewSubject returns a Subject with the provided ID holding roles.
+func NewSubject(id string, roles ...*Role) *Subject {
+	return &Subject{
+		ID:         id,
+		Roles:      roles,
+		Attributes: make(map[string]interface{}),
+	}
+}
+
+// EffectiveRoles returns the IDs of the subject roles and all of their sub roles in order.
+func (s *Subject) EffectiveRoles() ([]string, error) {
+	seen := make(map[string]bool)
+	ids := []string{}
+
+	for _, r := range s.Roles {
+		er, err := r.EffectiveRoles()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rr := range er {
+			if !seen[rr.ID] {
+				seen[rr.ID] = true
+				ids = append(ids, rr.ID)
+			}
+		}
+	}
+
+	return ids, nil
+}
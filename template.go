@@ -0,0 +1,200 @@
+package redtape
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	templateStart = "${"
+	templateEnd   = "}"
+
+	// templateReserved holds the characters a substituted value may not contain so it can't add wildcards or
+	// open and close regex segments. Values substituted inside a regex segment are additionally escaped with
+	// regexp.QuoteMeta so they only ever match themselves.
+	templateReserved = "*?<>"
+
+	regexStart = "<"
+	regexEnd   = ">"
+)
+
+// Policy Resources, Actions and Scopes may contain template variables substituted from the request at
+// match time:
+//
+//	${subject.id}          the request subject ID, see Request#SubjectID
+//	${subject.attr.<path>} the value of a Subject attribute
+//	${request.resource}    the request resource, and likewise action, role and scope
+//	${meta.<path>}         the value of a RequestMetadata key
+//
+// Attribute and metadata paths can reach into nested values, see Request#Lookup.
+var templateVars = map[string]func(r *Request) string{
+	"subject.id":       func(r *Request) string { return r.SubjectID() },
+	"request.resource": func(r *Request) string { return r.Resource },
+	"request.action":   func(r *Request) string { return r.Action },
+	"request.role":     func(r *Request) string { return r.Role },
+	"request.scope":    func(r *Request) string { return r.Scope },
+}
+
+// ValidateTemplate returns an error when s contains an unterminated or unknown template variable.
+func ValidateTemplate(s string) error {
+	_, err := templateNames(s)
+	return err
+}
+
+// templateNames returns the names of the variables referenced in s.
+func templateNames(s string) ([]string, error) {
+	var names []string
+
+	orig := s
+
+	for {
+		i := strings.Index(s, templateStart)
+		if i < 0 {
+			return names, nil
+		}
+
+		s = s[i+len(templateStart):]
+
+		j := strings.Index(s, templateEnd)
+		if j < 0 {
+			return nil, fmt.Errorf("unterminated template variable in %q", orig)
+		}
+
+		name := s[:j]
+		s = s[j+len(templateEnd):]
+
+		_, known := templateVars[name]
+		if !known && !hasKeyedPrefix(name, pathMeta) && !hasKeyedPrefix(name, pathSubjectAttr) {
+			return nil, fmt.Errorf("unknown template variable %q in %q", name, orig)
+		}
+
+		names = append(names, name)
+	}
+}
+
+// ExpandTemplate substitutes the template variables in s with values from r. It returns false when a
+// variable can not be resolved to a string or its value contains wildcard or regex delimiter characters.
+// Values substituted inside a <regex> segment have their regex metacharacters escaped.
+func ExpandTemplate(s string, r *Request) (string, bool) {
+	if !strings.Contains(s, templateStart) {
+		return s, true
+	}
+
+	var sb strings.Builder
+
+	for {
+		i := strings.Index(s, templateStart)
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), true
+		}
+
+		sb.WriteString(s[:i])
+		s = s[i+len(templateStart):]
+
+		j := strings.Index(s, templateEnd)
+		if j < 0 {
+			return "", false
+		}
+
+		val, ok := templateValue(s[:j], r)
+		if !ok || val == "" || strings.ContainsAny(val, templateReserved) {
+			return "", false
+		}
+
+		if inRegex(sb.String()) {
+			val = regexp.QuoteMeta(val)
+		}
+
+		sb.WriteString(val)
+		s = s[j+len(templateEnd):]
+	}
+}
+
+// inRegex evaluates true when the end of the expanded prefix s lies inside an unclosed regex segment.
+func inRegex(s string) bool {
+	return strings.Count(s, regexStart) > strings.Count(s, regexEnd)
+}
+
+func templateValue(name string, r *Request) (string, bool) {
+	if fn, ok := templateVars[name]; ok {
+		return fn(r), true
+	}
+
+	v, ok := r.Lookup(name)
+	if !ok {
+		return "", false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case fmt.Stringer:
+		return v.String(), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+// hasKeyedPrefix evaluates true when name starts with prefix followed by a non empty key.
+func hasKeyedPrefix(name, prefix string) bool {
+	return strings.HasPrefix(name, prefix) && len(name) > len(prefix)
+}
+
+// expandTemplates returns def with template variables substituted from r. Elements that can not be expanded
+// are dropped so they never match. A nil def is returned unchanged.
+func expandTemplates(def []string, r *Request) []string {
+	if def == nil {
+		return nil
+	}
+
+	expanded := make([]string, 0, len(def))
+	for _, h := range def {
+		if e, ok := ExpandTemplate(h, r); ok {
+			expanded = append(expanded, e)
+		}
+	}
+
+	return expanded
+}
+
+// templateMetaKeys returns the top level RequestMetadata keys referenced by the template variables in defs. The
+// second return value is true when defs reference Subject attributes.
+func templateMetaKeys(defs ...[]string) ([]string, bool) {
+	var keys []string
+
+	attrs := false
+
+	for _, def := range defs {
+		for _, h := range def {
+			names, _ := templateNames(h)
+			for _, n := range names {
+				if strings.HasPrefix(n, pathMeta) {
+					keys = append(keys, strings.SplitN(strings.TrimPrefix(n, pathMeta), ".", 2)[0])
+				}
+
+				if strings.HasPrefix(n, pathSubjectAttr) {
+					attrs = true
+				}
+			}
+		}
+	}
+
+	return keys, attrs
+}
+
+// validateTemplates returns an error for the first element of defs containing an invalid template.
+func validateTemplates(defs ...[]string) error {
+	for _, def := range defs {
+		for _, h := range def {
+			if err := ValidateTemplate(h); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package redtape
+
+import "testing"
+
+func TestExpandTemplate(t *testing.T) {
+	r := NewRequest("/users/alice/profile", "edit", "alice", "web", map[string]interface{}{
+		"tenant": "acme",
+		"shard":  3,
+		"glob":   "*",
+		"tags":   []string{"a"},
+		"org":    map[string]interface{}{"id": "acme"},
+	})
+
+	tests := []struct {
+		name   string
+		tmpl   string
+		want   string
+		wantOk bool
+	}{
+		{"plain", "/users/*", "/users/*", true},
+		{"subject", "/users/${subject.id}/*", "/users/alice/*", true},
+		{"request", "${request.scope}:${request.action}", "web:edit", true},
+		{"meta", "tenant:${meta.tenant}", "tenant:acme", true},
+		{"meta_number", "shard-${meta.shard}", "shard-3", true},
+		{"meta_missing", "tenant:${meta.region}", "", false},
+		{"meta_wildcard", "tenant:${meta.glob}", "", false},
+		{"meta_slice", "tag:${meta.tags}", "", false},
+		{"meta_nested", "/orgs/${meta.org.id}/*", "/orgs/acme/*", true},
+		{"meta_index", "tag:${meta.tags.0}", "tag:a", true},
+		{"subject_attr", "/orgs/${subject.attr.org}/*", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExpandTemplate(tt.tmpl, r)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("ExpandTemplate(%q) = %q, %v, want %q, %v", tt.tmpl, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{"plain", "/users/*", false},
+		{"subject", "/users/${subject.id}", false},
+		{"meta", "${meta.tenant}", false},
+		{"unterminated", "/users/${subject.id", true},
+		{"unknown", "/users/${user.id}", true},
+		{"empty_meta", "${meta.}", true},
+		{"subject_attr", "${subject.attr.org}", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTemplate(tt.tmpl); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTemplate(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpandSubjectTemplate(t *testing.T) {
+	sub := NewSubject("alice")
+	sub.Attributes["org"] = "acme"
+
+	r := NewSubjectRequest("/orgs/acme/repos", "read", sub, "")
+
+	for tmpl, want := range map[string]string{
+		"/users/${subject.id}":            "/users/alice",
+		"/orgs/${subject.attr.org}/*":     "/orgs/acme/*",
+		"${request.action}:${subject.id}": "read:alice",
+	} {
+		got, ok := ExpandTemplate(tmpl, r)
+		if !ok || got != want {
+			t.Errorf("ExpandTemplate(%q) = %q, %v, want %q", tmpl, got, ok, want)
+		}
+	}
+}
+
+func TestExpandRegexTemplate(t *testing.T) {
+	m := NewRegexMatcher()
+
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+		match   bool
+	}{
+		{"plain", "bob", `/users/<bob>/profile`, true},
+		{"dot_plus", ".+", `/users/<\.\+>/profile`, false},
+		{"alternation", "alice|bob", `/users/<alice\|bob>/profile`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSubjectRequest("/users/bob/profile", "read", NewSubject(tt.subject), "")
+
+			got, ok := ExpandTemplate("/users/<${subject.id}>/profile", r)
+			if !ok || got != tt.want {
+				t.Fatalf("ExpandTemplate() = %q, %v, want %q", got, ok, tt.want)
+			}
+
+			match, err := m.MatchPolicy(nil, []string{got}, r.Resource)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if match != tt.match {
+				t.Errorf("MatchPolicy(%q) = %v, want %v", got, match, tt.match)
+			}
+		})
+	}
+
+	outside, ok := ExpandTemplate("/users/${subject.id}/<[a-z]+>", NewSubjectRequest("", "", NewSubject("a.b"), ""))
+	if !ok || outside != "/users/a.b/<[a-z]+>" {
+		t.Errorf("ExpandTemplate() = %q, %v, want value outside regex segments unescaped", outside, ok)
+	}
+}
@@ -0,0 +1,115 @@
+package redtape
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceStage identifies a step of policy evaluation recorded in a Trace.
+type TraceStage string
+
+const (
+	// TraceStageValidity records checking the policy validity window against the enforcer clock.
+	TraceStageValidity TraceStage = "validity"
+	// TraceStageAction records matching the request action.
+	TraceStageAction TraceStage = "action"
+	// TraceStageRole records matching the request role.
+	TraceStageRole TraceStage = "role"
+	// TraceStageSubject records matching the request subject ID against the policy subjects.
+	TraceStageSubject TraceStage = "subject"
+	// TraceStageResource records matching the request resource.
+	TraceStageResource TraceStage = "resource"
+	// TraceStageScope records matching the request scope.
+	TraceStageScope TraceStage = "scope"
+	// TraceStageCondition records evaluating a named condition.
+	TraceStageCondition TraceStage = "condition"
+)
+
+// StageTrace records the outcome of a single evaluation stage. Value holds the request value that was
+// compared and Expected holds the policy definition it was compared against. Name is set to the
+// condition name for condition stages.
+type StageTrace struct {
+	Stage    TraceStage  `json:"stage"`
+	Name     string      `json:"name,omitempty"`
+	Value    interface{} `json:"value"`
+	Expected []string    `json:"expected"`
+	Passed   bool        `json:"passed"`
+}
+
+// PolicyTrace records how a single candidate policy was evaluated. Stages stop at the first stage that
+// failed to match.
+type PolicyTrace struct {
+	PolicyID string       `json:"policy_id"`
+	Name     string       `json:"name"`
+	Effect   PolicyEffect `json:"effect"`
+	Mode     PolicyMode   `json:"mode"`
+	Matched  bool         `json:"matched"`
+	Stages   []StageTrace `json:"stages"`
+}
+
+func newPolicyTrace(p Policy) *PolicyTrace {
+	return &PolicyTrace{
+		PolicyID: p.ID(),
+		Name:     p.Name(),
+		Effect:   p.Effect(),
+		Mode:     p.Mode(),
+	}
+}
+
+func (pt *PolicyTrace) record(stage TraceStage, name string, val interface{}, expected []string, passed bool) {
+	if pt == nil {
+		return
+	}
+
+	pt.Stages = append(pt.Stages, StageTrace{
+		Stage:    stage,
+		Name:     name,
+		Value:    val,
+		Expected: expected,
+		Passed:   passed,
+	})
+}
+
+// Trace records the evaluation of every candidate policy returned for a request.
+type Trace struct {
+	Policies []PolicyTrace `json:"policies"`
+}
+
+// String returns a human readable report of the Trace.
+func (t *Trace) String() string {
+	var sb strings.Builder
+
+	if len(t.Policies) == 0 {
+		sb.WriteString("no candidate policies\n")
+	}
+
+	for _, pt := range t.Policies {
+		res := "not matched"
+		if pt.Matched {
+			res = "matched"
+		}
+
+		effect := string(pt.Effect)
+		if pt.Mode == PolicyModeShadow {
+			effect += ", shadow"
+		}
+
+		fmt.Fprintf(&sb, "policy %s %q (%s): %s\n", pt.PolicyID, pt.Name, effect, res)
+
+		for _, st := range pt.Stages {
+			status := "fail"
+			if st.Passed {
+				status = "pass"
+			}
+
+			stage := string(st.Stage)
+			if st.Name != "" {
+				stage += " " + st.Name
+			}
+
+			fmt.Fprintf(&sb, "  [%s] %s: %#v against %v\n", status, stage, st.Value, st.Expected)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,208 @@
+package redtape
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule describes a recurring daily window in which a policy applies. Days holds weekday names such as
+// "mon" or "monday" and applies the window to every day when empty. Start and End are 24 hour "HH:MM"
+// times; an End before Start wraps past midnight. Location is an IANA time zone name defaulting to UTC and
+// is loaded once when the Schedule is part of a policy built by NewPolicy.
+type Schedule struct {
+	Days     []string `json:"days,omitempty"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Location string   `json:"location,omitempty"`
+
+	loc *time.Location
+}
+
+// Weekdays returns a Schedule applying from start to end on monday through friday in loc.
+func Weekdays(start, end, loc string) Schedule {
+	return Schedule{
+		Days:     []string{"mon", "tue", "wed", "thu", "fri"},
+		Start:    start,
+		End:      end,
+		Location: loc,
+	}
+}
+
+// Validate returns an error if the days, times or location of the Schedule cannot be parsed.
+func (s Schedule) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+// compile returns a copy of the Schedule holding its loaded location after validating it.
+func (s Schedule) compile() (Schedule, error) {
+	loc, err := time.LoadLocation(s.Location)
+	if err != nil {
+		return s, err
+	}
+
+	s.loc = loc
+
+	_, err = s.Contains(time.Time{})
+
+	return s, err
+}
+
+// Contains evaluates true when t falls within the Schedule.
+func (s Schedule) Contains(t time.Time) (bool, error) {
+	loc := s.loc
+	if loc == nil {
+		var err error
+		if loc, err = time.LoadLocation(s.Location); err != nil {
+			return false, err
+		}
+	}
+
+	start, err := parseClock(s.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := parseClock(s.End)
+	if err != nil {
+		return false, err
+	}
+
+	t = t.In(loc)
+	now := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	// a window wrapping past midnight belongs to the day it started on
+	if end <= start && now < end {
+		day = (day + 6) % 7
+	}
+
+	dayMatch := len(s.Days) == 0
+	for _, d := range s.Days {
+		wd, err := parseWeekday(d)
+		if err != nil {
+			return false, err
+		}
+
+		if wd == day {
+			dayMatch = true
+		}
+	}
+
+	if !dayMatch {
+		return false, nil
+	}
+
+	if end > start {
+		return now >= start && now < end, nil
+	}
+
+	return now >= start || now < end, nil
+}
+
+// String returns a compact description of the Schedule.
+func (s Schedule) String() string {
+	days := "daily"
+	if len(s.Days) > 0 {
+		days = strings.Join(s.Days, ",")
+	}
+
+	loc := s.Location
+	if loc == "" {
+		loc = "UTC"
+	}
+
+	return fmt.Sprintf("%s %s-%s %s", days, s.Start, s.End, loc)
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q: %w", s, err)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseWeekday returns the weekday named by s, either in full or by its three letter abbreviation.
+func parseWeekday(s string) (time.Weekday, error) {
+	d := strings.ToLower(s)
+
+	for abbr, wd := range weekdays {
+		if d == abbr || d == strings.ToLower(wd.String()) {
+			return wd, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid schedule day %q", s)
+}
+
+// Validity bounds the time in which a policy applies. A policy is active from NotBefore until NotAfter and,
+// when Schedules are set, only within at least one of them. Unset bounds are open.
+type Validity struct {
+	NotBefore *time.Time
+	NotAfter  *time.Time
+	Schedules []Schedule
+}
+
+// Bounded evaluates true when any bound or schedule is set.
+func (v Validity) Bounded() bool {
+	return v.NotBefore != nil || v.NotAfter != nil || len(v.Schedules) > 0
+}
+
+// Active evaluates true when the policy applies at t.
+func (v Validity) Active(t time.Time) bool {
+	if v.NotBefore != nil && t.Before(*v.NotBefore) {
+		return false
+	}
+
+	if v.NotAfter != nil && t.After(*v.NotAfter) {
+		return false
+	}
+
+	if len(v.Schedules) == 0 {
+		return true
+	}
+
+	for _, s := range v.Schedules {
+		if ok, err := s.Contains(t); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired evaluates true when the validity window ended before t.
+func (v Validity) Expired(t time.Time) bool {
+	return v.NotAfter != nil && t.After(*v.NotAfter)
+}
+
+func (v Validity) strings() []string {
+	var s []string
+
+	if v.NotBefore != nil {
+		s = append(s, "not_before="+v.NotBefore.Format(time.RFC3339))
+	}
+
+	if v.NotAfter != nil {
+		s = append(s, "not_after="+v.NotAfter.Format(time.RFC3339))
+	}
+
+	for _, sc := range v.Schedules {
+		s = append(s, "schedule="+sc.String())
+	}
+
+	return s
+}
@@ -0,0 +1,85 @@
+package redtape
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidityActive(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	// 2021-03-03 is a wednesday
+	wed := func(h, m int) time.Time {
+		return time.Date(2021, 3, 3, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		validity Validity
+		at       time.Time
+		want     bool
+	}{
+		{"unbounded", Validity{}, wed(3, 0), true},
+		{"before_window", Validity{NotBefore: &start}, start.Add(-time.Second), false},
+		{"in_window", Validity{NotBefore: &start, NotAfter: &end}, wed(12, 0), true},
+		{"after_window", Validity{NotAfter: &end}, end.Add(time.Second), false},
+		{"weekday_hours", Validity{Schedules: []Schedule{Weekdays("09:00", "17:00", "")}}, wed(9, 0), true},
+		{"weekday_closed", Validity{Schedules: []Schedule{Weekdays("09:00", "17:00", "")}}, wed(17, 0), false},
+		{"weekend", Validity{Schedules: []Schedule{Weekdays("09:00", "17:00", "")}}, wed(12, 0).AddDate(0, 0, 3), false},
+		{"time_zone", Validity{Schedules: []Schedule{Weekdays("09:00", "17:00", "America/New_York")}}, wed(15, 0), true},
+		{"time_zone_closed", Validity{Schedules: []Schedule{Weekdays("09:00", "17:00", "America/New_York")}}, wed(12, 0), false},
+		{"overnight", Validity{Schedules: []Schedule{{Days: []string{"tuesday"}, Start: "22:00", End: "02:00"}}}, wed(1, 0), true},
+		{"overnight_next_day", Validity{Schedules: []Schedule{{Days: []string{"tuesday"}, Start: "22:00", End: "02:00"}}}, wed(23, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.validity.Active(tt.at); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sched   Schedule
+		wantErr bool
+	}{
+		{"valid", Weekdays("09:00", "17:00", "Europe/Berlin"), false},
+		{"bad_time", Schedule{Start: "9am", End: "17:00"}, true},
+		{"bad_day", Schedule{Days: []string{"someday"}, Start: "09:00", End: "17:00"}, true},
+		{"bad_location", Schedule{Start: "09:00", End: "17:00", Location: "Nowhere/Null"}, true},
+		{"full_days", Schedule{Days: []string{"Thursday", "SAT"}, Start: "09:00", End: "17:00"}, false},
+		{"day_prefix", Schedule{Days: []string{"monkey"}, Start: "09:00", End: "17:00"}, true},
+		{"partial_day", Schedule{Days: []string{"tues"}, Start: "09:00", End: "17:00"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sched.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleLocation(t *testing.T) {
+	sched := Weekdays("09:00", "17:00", "America/New_York")
+
+	p, err := NewPolicy(WithSchedule(sched))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := p.Validity().Schedules[0]
+	if got.loc == nil || got.loc.String() != "America/New_York" {
+		t.Errorf("NewPolicy() schedule location = %v, want America/New_York", got.loc)
+	}
+
+	if sched.loc != nil {
+		t.Errorf("NewPolicy() modified the provided schedule")
+	}
+}